@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// DBSessionStore persists sessions in the database so they survive restarts
+// and can be shared by several API instances
+type DBSessionStore struct {
+	database *db.DB
+}
+
+func NewDBSessionStore(database *db.DB) *DBSessionStore {
+	store := &DBSessionStore{database: database}
+
+	// Start a cleanup goroutine
+	go store.cleanupExpiredSessions()
+
+	return store
+}
+
+func (s *DBSessionStore) CreateSession(user *models.User, userAgent, ipAddress string) (*models.Session, error) {
+	now := time.Now().UTC()
+	session := &models.Session{
+		ID:         utils.GenerateSessionID(),
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(sessionTTL), // 72-hour sessions
+	}
+
+	if err := s.database.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *DBSessionStore) GetSession(sessionID string) (*models.Session, bool) {
+	session, err := s.database.GetSessionByToken(sessionID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			utils.LogError("Failed to load session: %v", err)
+		}
+		return nil, false
+	}
+
+	// Check if expired
+	if time.Now().After(session.ExpiresAt) {
+		s.DeleteSession(sessionID)
+		return nil, false
+	}
+
+	// Extend the session expiry on each access (auto-renewal)
+	session.LastSeenAt = time.Now().UTC()
+	session.ExpiresAt = session.LastSeenAt.Add(sessionTTL)
+	if err := s.database.TouchSession(sessionID, session.LastSeenAt, session.ExpiresAt); err != nil {
+		utils.LogError("Failed to extend session %s: %v", sessionID[:8]+"...", err)
+	} else {
+		utils.LogInfo("Session %s extended until %v", sessionID[:8]+"...", session.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return session, true
+}
+
+func (s *DBSessionStore) DeleteSession(sessionID string) {
+	if err := s.database.DeleteSession(sessionID); err != nil {
+		utils.LogError("Failed to delete session: %v", err)
+	}
+}
+
+func (s *DBSessionStore) DeleteUserSessions(userID int) {
+	if _, err := s.database.DeleteUserSessions(userID); err != nil {
+		utils.LogError("Failed to delete sessions for user %d: %v", userID, err)
+	}
+}
+
+func (s *DBSessionStore) ListUserSessions(userID int) ([]models.SessionInfo, error) {
+	return s.database.GetUserSessions(userID)
+}
+
+func (s *DBSessionStore) DeleteUserSession(userID int, sessionHash string) (bool, error) {
+	return s.database.DeleteUserSessionByHash(userID, sessionHash)
+}
+
+func (s *DBSessionStore) DeleteOtherUserSessions(userID int, keepSessionID string) (int, error) {
+	deleted, err := s.database.DeleteOtherUserSessions(userID, keepSessionID)
+	return int(deleted), err
+}
+
+func (s *DBSessionStore) cleanupExpiredSessions() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cleaned, err := s.database.DeleteExpiredSessions()
+		if err != nil {
+			utils.LogError("Failed to clean up expired sessions: %v", err)
+			continue
+		}
+		if cleaned > 0 {
+			utils.LogInfo("Cleaned up %d expired sessions", cleaned)
+		}
+	}
+}
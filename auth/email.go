@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/smtp"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/adamspd/QuizzApi/models"
@@ -15,16 +16,18 @@ import (
 // LoadEmailConfig loads email configuration from environment
 func LoadEmailConfig() *models.EmailConfig {
 	gracePeriodHours := utils.GetEnvInt("EMAIL_GRACE_PERIOD_HOURS", 2)
+	passwordResetMinutes := utils.GetEnvInt("PASSWORD_RESET_TTL_MINUTES", 60)
 
 	return &models.EmailConfig{
-		SMTPHost:    utils.GetEnvOrDefault("SMTP_HOST", "mail.adamspierredavid.com"),
-		SMTPPort:    utils.GetEnvInt("SMTP_PORT", 465),
-		Username:    utils.GetEnvOrDefault("SMTP_USERNAME", ""),
-		Password:    utils.GetEnvOrDefault("SMTP_PASSWORD", ""),
-		FromAddress: utils.GetEnvOrDefault("FROM_EMAIL", "noreply@adamspierredavid.com"),
-		FromName:    utils.GetEnvOrDefault("FROM_NAME", "French Citizenship Training"),
-		BaseURL:     utils.GetEnvOrDefault("BASE_URL", "http://localhost:8043"),
-		GracePeriod: time.Duration(gracePeriodHours) * time.Hour,
+		SMTPHost:         utils.GetEnvOrDefault("SMTP_HOST", "mail.adamspierredavid.com"),
+		SMTPPort:         utils.GetEnvInt("SMTP_PORT", 465),
+		Username:         utils.GetEnvOrDefault("SMTP_USERNAME", ""),
+		Password:         utils.GetEnvOrDefault("SMTP_PASSWORD", ""),
+		FromAddress:      utils.GetEnvOrDefault("FROM_EMAIL", "noreply@adamspierredavid.com"),
+		FromName:         utils.GetEnvOrDefault("FROM_NAME", "French Citizenship Training"),
+		BaseURL:          utils.GetEnvOrDefault("BASE_URL", "http://localhost:8043"),
+		GracePeriod:      time.Duration(gracePeriodHours) * time.Hour,
+		PasswordResetTTL: time.Duration(passwordResetMinutes) * time.Minute,
 	}
 }
 
@@ -62,6 +65,28 @@ French Citizenship Training Team`, user.Username, verificationURL, int(es.config
 	return subject, body
 }
 
+func (es *EmailService) BuildPasswordResetEmail(user *models.User, token string) (string, string) {
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", es.config.BaseURL, url.QueryEscape(token))
+
+	subject := "Reset your password"
+	body := fmt.Sprintf(`Hello %s,
+
+We received a request to reset the password of your French Citizenship Training account.
+
+Please click the link below to choose a new password:
+%s
+
+This link can only be used once and will expire in %d minutes.
+Once your password has been changed, you will be logged out of all your devices.
+
+If you didn't request a password reset, please ignore this email. Your password will not be changed.
+
+Best regards,
+French Citizenship Training Team`, user.Username, resetURL, int(es.config.PasswordResetTTL.Minutes()))
+
+	return subject, body
+}
+
 func (es *EmailService) SendEmail(to, subject, body string) error {
 	if es.config.Username == "" || es.config.Password == "" {
 		utils.LogInfo("SMTP not configured, logging email instead")
@@ -88,7 +113,7 @@ func (es *EmailService) sendEmail(to, subject, body string) error {
 		"%s\r\n", es.config.FromName, es.config.FromAddress, to, subject, body)
 
 	// For port 465 (implicit SSL), we need to establish SSL connection first
-	addr := fmt.Sprintf("%s:%d", es.config.SMTPHost, es.config.SMTPPort)
+	addr := net.JoinHostPort(es.config.SMTPHost, strconv.Itoa(es.config.SMTPPort))
 
 	var conn net.Conn
 	var err error
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -8,13 +9,30 @@ import (
 	"github.com/adamspd/QuizzApi/utils"
 )
 
-type SessionStore struct {
+// sessionTTL is the sliding session lifetime, renewed on every access
+const sessionTTL = 72 * time.Hour
+
+// SessionStore is the session backend used by the handlers and middleware
+type SessionStore interface {
+	CreateSession(user *models.User, userAgent, ipAddress string) (*models.Session, error)
+	GetSession(sessionID string) (*models.Session, bool)
+	DeleteSession(sessionID string)
+	DeleteUserSessions(userID int)
+
+	// Self-service management, sessions are identified by the hash of their token
+	ListUserSessions(userID int) ([]models.SessionInfo, error)
+	DeleteUserSession(userID int, sessionHash string) (bool, error)
+	DeleteOtherUserSessions(userID int, keepSessionID string) (int, error)
+}
+
+// MemorySessionStore keeps sessions in process memory, they are lost on restart
+type MemorySessionStore struct {
 	sessions map[string]*models.Session
 	mutex    sync.RWMutex
 }
 
-func NewSessionStore() *SessionStore {
-	store := &SessionStore{
+func NewMemorySessionStore() *MemorySessionStore {
+	store := &MemorySessionStore{
 		sessions: make(map[string]*models.Session),
 	}
 
@@ -24,25 +42,28 @@ func NewSessionStore() *SessionStore {
 	return store
 }
 
-func (s *SessionStore) CreateSession(user *models.User) *models.Session {
+func (s *MemorySessionStore) CreateSession(user *models.User, userAgent, ipAddress string) (*models.Session, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	sessionID := utils.GenerateSessionID()
 	session := &models.Session{
-		ID:        sessionID,
-		UserID:    user.ID,
-		Username:  user.Username,
-		Role:      user.Role,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(72 * time.Hour), // 72-hour sessions
+		ID:         sessionID,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  time.Now(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(sessionTTL), // 72-hour sessions
 	}
 
 	s.sessions[sessionID] = session
-	return session
+	return session, nil
 }
 
-func (s *SessionStore) GetSession(sessionID string) (*models.Session, bool) {
+func (s *MemorySessionStore) GetSession(sessionID string) (*models.Session, bool) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -58,19 +79,20 @@ func (s *SessionStore) GetSession(sessionID string) (*models.Session, bool) {
 	}
 
 	// Extend the session expiry on each access (auto-renewal)
-	session.ExpiresAt = time.Now().Add(72 * time.Hour)
+	session.LastSeenAt = time.Now()
+	session.ExpiresAt = time.Now().Add(sessionTTL)
 	utils.LogInfo("Session %s extended until %v", sessionID[:8]+"...", session.ExpiresAt.Format("2006-01-02 15:04:05"))
 
 	return session, true
 }
 
-func (s *SessionStore) DeleteSession(sessionID string) {
+func (s *MemorySessionStore) DeleteSession(sessionID string) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	delete(s.sessions, sessionID)
 }
 
-func (s *SessionStore) DeleteUserSessions(userID int) {
+func (s *MemorySessionStore) DeleteUserSessions(userID int) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -81,7 +103,58 @@ func (s *SessionStore) DeleteUserSessions(userID int) {
 	}
 }
 
-func (s *SessionStore) cleanupExpiredSessions() {
+func (s *MemorySessionStore) ListUserSessions(userID int) ([]models.SessionInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sessions := []models.SessionInfo{}
+	for id, session := range s.sessions {
+		if session.UserID == userID && time.Now().Before(session.ExpiresAt) {
+			sessions = append(sessions, models.SessionInfo{
+				ID:         utils.HashToken(id),
+				UserAgent:  session.UserAgent,
+				IPAddress:  session.IPAddress,
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				ExpiresAt:  session.ExpiresAt,
+			})
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (s *MemorySessionStore) DeleteUserSession(userID int, sessionHash string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && utils.HashToken(id) == sessionHash {
+			delete(s.sessions, id)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemorySessionStore) DeleteOtherUserSessions(userID int, keepSessionID string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deleted := 0
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != keepSessionID {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *MemorySessionStore) cleanupExpiredSessions() {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
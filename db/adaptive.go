@@ -0,0 +1,150 @@
+package db
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+const (
+	// adaptiveWindow is how many recent answers the rolling accuracy is computed on
+	adaptiveWindow = 20
+	// adaptiveMinAnswers below which there isn't enough history to adapt
+	adaptiveMinAnswers = 5
+	// adaptivePoolFactor is how many candidates are fetched per question served
+	adaptivePoolFactor = 5
+)
+
+// difficultyMix is the share of easy, medium and hard questions to serve
+type difficultyMix map[string]float64
+
+// getRollingAccuracy returns the accuracy over the user's last answers and how many answers it covers
+func (db *DB) getRollingAccuracy(userID int) (float64, int, error) {
+	var answered, correct int
+	err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN is_correct THEN 1 ELSE 0 END), 0)
+		FROM (SELECT is_correct FROM progress WHERE user_id = ? ORDER BY answered_at DESC, id DESC LIMIT ?)
+	`, userID, adaptiveWindow).Scan(&answered, &correct)
+	if err != nil {
+		utils.LogError("Failed to compute rolling accuracy for user %d: %v", userID, err)
+		return 0, 0, err
+	}
+
+	if answered == 0 {
+		return 0, 0, nil
+	}
+	return float64(correct) / float64(answered), answered, nil
+}
+
+// adaptiveDifficultyMix raises the share of hard questions as the rolling accuracy goes up
+func adaptiveDifficultyMix(accuracy float64, answered int) difficultyMix {
+	switch {
+	case answered < adaptiveMinAnswers:
+		return difficultyMix{"easy": 0.3, "medium": 0.5, "hard": 0.2}
+	case accuracy < 0.5:
+		return difficultyMix{"easy": 0.6, "medium": 0.3, "hard": 0.1}
+	case accuracy < 0.75:
+		return difficultyMix{"easy": 0.3, "medium": 0.5, "hard": 0.2}
+	case accuracy < 0.9:
+		return difficultyMix{"easy": 0.15, "medium": 0.45, "hard": 0.4}
+	default:
+		return difficultyMix{"easy": 0.1, "medium": 0.3, "hard": 0.6}
+	}
+}
+
+// categoryWeakness weights a category by its smoothed error rate, from 0.5 (always right) to 2.5 (always wrong).
+// Categories never answered sit in the middle so they still get explored.
+func categoryWeakness(stat models.CategoryStat, answered bool) float64 {
+	if !answered {
+		return 1.5
+	}
+	errorRate := float64(stat.Answered-stat.Correct+1) / float64(stat.Answered+2)
+	return 0.5 + 2*errorRate
+}
+
+// selectAdaptiveQuestions picks count questions out of the candidate pool by weighted sampling.
+// Candidates come ordered by the regular prioritization, earlier ones keep an edge and the
+// selection is returned in that same order.
+func selectAdaptiveQuestions(candidates []models.Question, count int, mix difficultyMix, categories map[string]models.CategoryStat) []models.Question {
+	if len(candidates) <= count {
+		return candidates
+	}
+
+	// Spread each difficulty's share over the candidates of that difficulty so the mix
+	// doesn't depend on how many of each the pool happens to contain
+	perDifficulty := make(map[string]int)
+	for _, q := range candidates {
+		perDifficulty[q.Difficulty]++
+	}
+
+	type keyed struct {
+		index int
+		key   float64
+	}
+	keys := make([]keyed, 0, len(candidates))
+
+	for i, q := range candidates {
+		weight := 1.0
+		if mix != nil {
+			weight = mix[q.Difficulty] / float64(perDifficulty[q.Difficulty])
+		}
+		if categories != nil {
+			stat, answered := categories[q.Category]
+			weight *= categoryWeakness(stat, answered)
+		}
+		weight *= 1 / (1 + float64(i)/float64(count))
+
+		// Efraimidis-Spirakis: the top keys u^(1/w) are a weighted sample without replacement. They are
+		// compared as log(u)/w, which orders the same but doesn't underflow to 0 for small weights. A
+		// weight of 0 gives -Inf, only picked once everything else is.
+		keys = append(keys, keyed{index: i, key: math.Log(rand.Float64()) / weight})
+	}
+
+	sort.Slice(keys, func(a, b int) bool { return keys[a].key > keys[b].key })
+	keys = keys[:count]
+	sort.Slice(keys, func(a, b int) bool { return keys[a].index < keys[b].index })
+
+	selected := make([]models.Question, 0, count)
+	for _, k := range keys {
+		selected = append(selected, candidates[k.index])
+	}
+	return selected
+}
+
+// applyAdaptiveSelection narrows the candidate pool down to count questions. If the user's
+// history can't be read, it falls back to the regular prioritization.
+func (db *DB) applyAdaptiveSelection(userID int, candidates []models.Question, count int, adaptive, focusWeakAreas bool) []models.Question {
+	var mix difficultyMix
+	if adaptive {
+		accuracy, answered, err := db.getRollingAccuracy(userID)
+		if err != nil {
+			return truncateQuestions(candidates, count)
+		}
+		mix = adaptiveDifficultyMix(accuracy, answered)
+		utils.LogDB("Adaptive difficulty for user %d: accuracy %.0f%% over %d answers -> easy %.0f%%, medium %.0f%%, hard %.0f%%",
+			userID, accuracy*100, answered, mix["easy"]*100, mix["medium"]*100, mix["hard"]*100)
+	}
+
+	var categories map[string]models.CategoryStat
+	if focusWeakAreas {
+		stats, err := db.getCategoryStats(userID)
+		if err != nil {
+			return truncateQuestions(candidates, count)
+		}
+		categories = stats
+	}
+
+	selected := selectAdaptiveQuestions(candidates, count, mix, categories)
+	utils.LogDB("Adaptive selection kept %d of %d candidates", len(selected), len(candidates))
+	return selected
+}
+
+func truncateQuestions(questions []models.Question, count int) []models.Question {
+	if len(questions) > count {
+		return questions[:count]
+	}
+	return questions
+}
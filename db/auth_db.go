@@ -286,6 +286,47 @@ func (db *DB) DeleteUserPermanently(id int) error {
 		return err
 	}
 
+	// Delete spaced repetition schedules
+	_, err = tx.Exec("DELETE FROM question_schedules WHERE user_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete question schedules for user %d: %v", id, err)
+		return err
+	}
+
+	// Delete practice sessions
+	_, err = tx.Exec("DELETE FROM practice_sessions WHERE user_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete practice sessions for user %d: %v", id, err)
+		return err
+	}
+
+	// Delete exam attempts and their answers
+	_, err = tx.Exec("DELETE FROM exam_attempt_answers WHERE attempt_id IN (SELECT id FROM exam_attempts WHERE user_id = ?)", id)
+	if err != nil {
+		utils.LogError("Failed to delete exam answers for user %d: %v", id, err)
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM exam_attempts WHERE user_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete exam attempts for user %d: %v", id, err)
+		return err
+	}
+
+	// Release the questions the user was reviewing
+	_, err = tx.Exec("UPDATE questions SET assigned_to = NULL, assigned_at = NULL WHERE assigned_to = ?", id)
+	if err != nil {
+		utils.LogError("Failed to release question assignments for user %d: %v", id, err)
+		return err
+	}
+
+	// Delete question comments, moderation decisions stay for the audit trail
+	_, err = tx.Exec("DELETE FROM question_comments WHERE user_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete question comments for user %d: %v", id, err)
+		return err
+	}
+
 	// Delete email verifications
 	_, err = tx.Exec("DELETE FROM email_verifications WHERE user_id = ?", id)
 	if err != nil {
@@ -293,6 +334,20 @@ func (db *DB) DeleteUserPermanently(id int) error {
 		return err
 	}
 
+	// Delete password resets
+	_, err = tx.Exec("DELETE FROM password_resets WHERE user_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete password resets for user %d: %v", id, err)
+		return err
+	}
+
+	// Delete sessions
+	_, err = tx.Exec("DELETE FROM sessions WHERE user_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete sessions for user %d: %v", id, err)
+		return err
+	}
+
 	// Finally delete the user
 	result, err := tx.Exec("DELETE FROM users WHERE id = ?", id)
 	if err != nil {
@@ -454,6 +509,106 @@ func (db *DB) VerifyEmailToken(token string) (*models.User, error) {
 	return &user, nil
 }
 
+// Password reset functions
+func (db *DB) CreatePasswordReset(userID int, ttl time.Duration) (*models.PasswordReset, error) {
+	utils.LogDB("Creating password reset for user %d", userID)
+
+	// Only the most recent reset link stays valid
+	_, err := db.Exec("DELETE FROM password_resets WHERE user_id = ? AND used_at IS NULL", userID)
+	if err != nil {
+		utils.LogError("Failed to clean up old password reset tokens: %v", err)
+		return nil, err
+	}
+
+	token := utils.GenerateVerificationToken()
+	expiresAt := time.Now().Add(ttl)
+
+	result, err := db.Exec(`
+		INSERT INTO password_resets (user_id, token_hash, created_at, expires_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP, ?)
+	`, userID, utils.HashToken(token), expiresAt)
+
+	if err != nil {
+		utils.LogError("Failed to create password reset: %v", err)
+		return nil, err
+	}
+
+	id, _ := result.LastInsertId()
+
+	reset := &models.PasswordReset{
+		ID:        int(id),
+		UserID:    userID,
+		Token:     token,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	utils.LogDB("Password reset created for user %d (expires %v)", userID, expiresAt.Format("2006-01-02 15:04:05"))
+	return reset, nil
+}
+
+func (db *DB) ResetPasswordWithToken(token, newPassword string) (*models.User, error) {
+	if len(token) < 8 {
+		return nil, fmt.Errorf("invalid or expired reset token")
+	}
+	utils.LogDB("Resetting password with token: %s", token[:8]+"...")
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var reset models.PasswordReset
+	err = tx.QueryRow(`
+		SELECT id, user_id, created_at, expires_at, used_at
+		FROM password_resets
+		WHERE token_hash = ? AND used_at IS NULL
+	`, utils.HashToken(token)).Scan(&reset.ID, &reset.UserID, &reset.CreatedAt, &reset.ExpiresAt, &reset.UsedAt)
+
+	if err != nil {
+		utils.LogDB("Password reset token not found or already used: %s", token[:8]+"...")
+		return nil, fmt.Errorf("invalid or expired reset token")
+	}
+
+	if time.Now().After(reset.ExpiresAt) {
+		utils.LogDB("Password reset token expired: %s", token[:8]+"...")
+		return nil, fmt.Errorf("invalid or expired reset token")
+	}
+
+	// Mark token as used so it cannot be replayed
+	_, err = tx.Exec("UPDATE password_resets SET used_at = CURRENT_TIMESTAMP WHERE id = ?", reset.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET password_hash = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND is_active = 1
+	`, hashedPassword, reset.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		utils.LogDB("Password reset refused: user %d not found or inactive", reset.UserID)
+		return nil, fmt.Errorf("invalid or expired reset token")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	utils.LogDB("Password reset completed for user %d", reset.UserID)
+	return db.GetUserByID(reset.UserID)
+}
+
 func (db *DB) IsUserInGracePeriod(userID int, gracePeriod time.Duration) (bool, error) {
 	var createdAt time.Time
 	err := db.QueryRow("SELECT created_at FROM users WHERE id = ?", userID).Scan(&createdAt)
@@ -0,0 +1,519 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// defaultCategories are the themes of the civic exam, seeded on a database without categories
+var defaultCategories = []string{"symboles", "personnalités", "politique", "histoire", "laïcité", "valeurs",
+	"société", "citoyenneté", "patrimoine", "culture", "géographie", "europe", "sciences"}
+
+// seedCategories fills an empty categories table with the default categories, then adds every category
+// still only known from the questions, after the existing ones
+func seedCategories(db *sql.DB) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count); err != nil {
+		return err
+	}
+
+	var missing []string
+	if count == 0 {
+		missing = append(missing, defaultCategories...)
+	}
+
+	rows, err := db.Query(`
+		SELECT DISTINCT category FROM questions
+		WHERE category NOT IN (SELECT slug FROM categories)
+		ORDER BY category
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return err
+		}
+		if count > 0 || !containsString(defaultCategories, category) {
+			missing = append(missing, category)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var position int
+	if err := db.QueryRow("SELECT COALESCE(MAX(position), 0) FROM categories").Scan(&position); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, slug := range missing {
+		position++
+		_, err := db.Exec(`
+			INSERT OR IGNORE INTO categories (slug, name, position, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, slug, utils.CategoryDisplayName(slug), position, now, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	utils.LogDB("Seeded %d categories", len(missing))
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// slugIndex resolves what a user typed as a category or tag to its slug: the slug itself, or the slug
+// or display name in any case
+type slugIndex struct {
+	slugs  map[string]bool
+	folded map[string]string
+}
+
+func (idx *slugIndex) resolve(category string) (string, bool) {
+	category = strings.TrimSpace(category)
+	if idx.slugs[category] {
+		return category, true
+	}
+	slug, ok := idx.folded[strings.ToLower(category)]
+	return slug, ok
+}
+
+func (db *DB) loadCategoryIndex() (*slugIndex, error) {
+	return db.loadSlugIndex("categories")
+}
+
+// loadSlugIndex indexes the slugs and names of the categories or tags table
+func (db *DB) loadSlugIndex(table string) (*slugIndex, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT slug, name FROM %s ORDER BY id", table))
+	if err != nil {
+		utils.LogError("Failed to fetch %s: %v", table, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	idx := &slugIndex{slugs: make(map[string]bool), folded: make(map[string]string)}
+	var names [][2]string
+	for rows.Next() {
+		var slug, name string
+		if err := rows.Scan(&slug, &name); err != nil {
+			return nil, err
+		}
+		idx.slugs[slug] = true
+		if _, taken := idx.folded[strings.ToLower(slug)]; !taken {
+			idx.folded[strings.ToLower(slug)] = slug
+		}
+		names = append(names, [2]string{slug, name})
+	}
+
+	// Slugs win over display names when they collide
+	for _, n := range names {
+		if _, taken := idx.folded[strings.ToLower(n[1])]; !taken {
+			idx.folded[strings.ToLower(n[1])] = n[0]
+		}
+	}
+
+	return idx, rows.Err()
+}
+
+// ResolveCategories maps category names or slugs to their slugs, failing on the first unknown one
+func (db *DB) ResolveCategories(categories []string) ([]string, error) {
+	idx, err := db.loadCategoryIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(categories))
+	for _, category := range categories {
+		slug, ok := idx.resolve(category)
+		if !ok {
+			return nil, fmt.Errorf("invalid category: %s", category)
+		}
+		slugs = append(slugs, slug)
+	}
+	return slugs, nil
+}
+
+// resolveQuestionCategory checks the category of a created or updated question
+func (db *DB) resolveQuestionCategory(category string) (string, error) {
+	if strings.TrimSpace(category) == "" {
+		return "", fmt.Errorf("invalid question: category is required")
+	}
+
+	idx, err := db.loadCategoryIndex()
+	if err != nil {
+		return "", err
+	}
+	slug, ok := idx.resolve(category)
+	if !ok {
+		return "", fmt.Errorf("invalid question: unknown category '%s'", strings.TrimSpace(category))
+	}
+	return slug, nil
+}
+
+const categoryColumns = `c.id, c.slug, c.name, c.description, c.position, c.parent_id, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM questions q WHERE q.category = c.slug AND q.status = 'approved')`
+
+func scanCategory(scanner interface{ Scan(...interface{}) error }) (*models.Category, error) {
+	var c models.Category
+	err := scanner.Scan(&c.ID, &c.Slug, &c.Name, &c.Description, &c.Position, &c.ParentID, &c.CreatedAt,
+		&c.UpdatedAt, &c.QuestionCount)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCategories returns every category in display order
+func (db *DB) GetCategories() ([]models.Category, error) {
+	utils.LogDB("Executing query: GetCategories")
+
+	rows, err := db.Query(`SELECT ` + categoryColumns + ` FROM categories c ORDER BY c.position, c.name`)
+	if err != nil {
+		utils.LogError("GetCategories failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			utils.LogError("Failed to scan category: %v", err)
+			return nil, err
+		}
+		categories = append(categories, *c)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetCategory returns a single category
+func (db *DB) GetCategory(id int) (*models.Category, error) {
+	c, err := scanCategory(db.QueryRow(`SELECT `+categoryColumns+` FROM categories c WHERE c.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("category not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to get category %d: %v", id, err)
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkCategoryParent makes sure the parent exists and is not the category itself or one of its
+// sub-categories. id is 0 for a new category.
+func checkCategoryParent(tx *sql.Tx, id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+
+	current := *parentID
+	for depth := 0; ; depth++ {
+		if current == id || depth > 100 {
+			return fmt.Errorf("a category can not be nested under itself or one of its sub-categories")
+		}
+
+		var next sql.NullInt64
+		err := tx.QueryRow("SELECT parent_id FROM categories WHERE id = ?", current).Scan(&next)
+		if err == sql.ErrNoRows {
+			if current == *parentID {
+				return fmt.Errorf("parent category not found")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !next.Valid {
+			return nil
+		}
+		current = int(next.Int64)
+	}
+}
+
+// CreateCategory adds a category, placed after the others unless a position is given
+func (db *DB) CreateCategory(req models.CategoryRequest) (*models.Category, error) {
+	utils.LogDB("Creating category '%s' (%s)", req.Name, req.Slug)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkCategoryParent(tx, 0, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	var position int
+	if req.Position != nil {
+		position = *req.Position
+	} else if err := tx.QueryRow("SELECT COALESCE(MAX(position), 0) + 1 FROM categories").Scan(&position); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.Exec(`
+		INSERT INTO categories (slug, name, description, position, parent_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, req.Slug, req.Name, req.Description, position, req.ParentID, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("category slug already exists")
+		}
+		utils.LogError("Failed to create category: %v", err)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit category creation: %v", err)
+		return nil, err
+	}
+
+	utils.LogDB("Category %d '%s' created", id, req.Slug)
+	return db.GetCategory(int(id))
+}
+
+// UpdateCategory replaces the name, description, position and parent of a category
+func (db *DB) UpdateCategory(id int, req models.CategoryRequest) (*models.Category, error) {
+	utils.LogDB("Updating category %d", id)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var slug string
+	var position int
+	err = tx.QueryRow("SELECT slug, position FROM categories WHERE id = ?", id).Scan(&slug, &position)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Slug != "" && req.Slug != slug {
+		return nil, fmt.Errorf("category slug can not be changed")
+	}
+	if err := checkCategoryParent(tx, id, req.ParentID); err != nil {
+		return nil, err
+	}
+	if req.Position != nil {
+		position = *req.Position
+	}
+
+	_, err = tx.Exec(`
+		UPDATE categories SET name = ?, description = ?, position = ?, parent_id = ?, updated_at = ?
+		WHERE id = ?
+	`, req.Name, req.Description, position, req.ParentID, time.Now().UTC(), id)
+	if err != nil {
+		utils.LogError("Failed to update category %d: %v", id, err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit category update: %v", err)
+		return nil, err
+	}
+
+	return db.GetCategory(id)
+}
+
+// DeleteCategory removes a category without sub-categories. Its questions are moved to the category
+// reassignTo names; without one, a category still holding questions is not deleted. Returns the number
+// of questions moved, each of them gets a revision recording the move.
+func (db *DB) DeleteCategory(id int, reassignTo string, adminID int) (int, error) {
+	utils.LogDB("Deleting category %d (questions reassigned to '%s')", id, reassignTo)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var slug string
+	err = tx.QueryRow("SELECT slug FROM categories WHERE id = ?", id).Scan(&slug)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("category not found")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var children int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM categories WHERE parent_id = ?", id).Scan(&children); err != nil {
+		return 0, err
+	}
+	if children > 0 {
+		return 0, fmt.Errorf("category has %d sub-categories, move or delete them first", children)
+	}
+
+	var target string
+	if reassignTo != "" {
+		err := tx.QueryRow("SELECT slug FROM categories WHERE slug = ? AND id != ?", reassignTo, id).Scan(&target)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("reassign_to category not found")
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var questions int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM questions WHERE category = ?", slug).Scan(&questions); err != nil {
+		return 0, err
+	}
+
+	if questions > 0 {
+		if target == "" {
+			return 0, fmt.Errorf("category is used by %d questions, reassign them with ?reassign_to=", questions)
+		}
+		if err := reassignCategoryQuestions(tx, slug, target, adminID); err != nil {
+			utils.LogError("Failed to reassign questions of category %d: %v", id, err)
+			return 0, err
+		}
+	}
+
+	// Blueprint sections drawing from the category would fail on every exam start
+	blueprints, err := reassignBlueprintCategory(tx, slug, target)
+	if err != nil {
+		utils.LogError("Failed to reassign exam blueprints of category %d: %v", id, err)
+		return 0, err
+	}
+	if blueprints > 0 && target == "" {
+		return 0, fmt.Errorf("category is used by %d exam blueprints, reassign them with ?reassign_to=", blueprints)
+	}
+
+	// Learners who picked the category follow it to its replacement, or lose it from their preference
+	if err := replacePreferenceSlug(tx, "category_preference", slug, target); err != nil {
+		utils.LogError("Failed to update category preferences for category %d: %v", id, err)
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = ?", id); err != nil {
+		utils.LogError("Failed to delete category %d: %v", id, err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit category deletion: %v", err)
+		return 0, err
+	}
+
+	utils.LogDB("Category %d '%s' deleted, %d questions moved to '%s'", id, slug, questions, reassignTo)
+	return questions, nil
+}
+
+// reassignBlueprintCategory points the blueprint sections drawing from a category to another one and
+// returns how many blueprints use the category. With no target nothing is changed.
+func reassignBlueprintCategory(tx *sql.Tx, from, to string) (int, error) {
+	rows, err := tx.Query("SELECT id, sections FROM exam_blueprints")
+	if err != nil {
+		return 0, err
+	}
+
+	updated := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var sectionsJSON string
+		if err := rows.Scan(&id, &sectionsJSON); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		var sections []models.ExamSection
+		if err := json.Unmarshal([]byte(sectionsJSON), &sections); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("invalid sections in blueprint %d: %w", id, err)
+		}
+
+		used := false
+		for i := range sections {
+			if sections[i].Category == from {
+				sections[i].Category = to
+				used = true
+			}
+		}
+		if used {
+			data, _ := json.Marshal(sections)
+			updated[id] = string(data)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if to != "" {
+		for id, sectionsJSON := range updated {
+			_, err := tx.Exec("UPDATE exam_blueprints SET sections = ?, updated_at = ? WHERE id = ?",
+				sectionsJSON, time.Now().UTC(), id)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(updated), nil
+}
+
+// reassignCategoryQuestions moves every question of a category to another one
+func reassignCategoryQuestions(tx *sql.Tx, from, to string, changedBy int) error {
+	rows, err := tx.Query("SELECT id FROM questions WHERE category = ?", from)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE questions SET category = ?, updated_at = ? WHERE category = ?", to, time.Now().UTC(), from); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := recordQuestionRevision(tx, id, &changedBy, "update"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 type DB struct {
 	*sql.DB
+	searchEnabled bool // false when SQLite was built without FTS5
 }
 
 func InitDB(dbPath string) (*DB, error) {
@@ -34,7 +35,15 @@ func InitDB(dbPath string) (*DB, error) {
 	}
 
 	utils.LogStartup("Database tables initialized successfully")
-	return &DB{db}, nil
+
+	// Search is optional, the rest of the API works without it
+	searchEnabled := true
+	if err := setupQuestionSearch(db); err != nil {
+		utils.LogError("Full-text search disabled, build with -tags sqlite_fts5 to enable it: %v", err)
+		searchEnabled = false
+	}
+
+	return &DB{DB: db, searchEnabled: searchEnabled}, nil
 }
 
 func createTablesWithAuth(db *sql.DB) error {
@@ -65,11 +74,35 @@ func createTablesWithAuth(db *sql.DB) error {
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)`,
 
+		// Password reset tokens table (only token hashes are stored)
+		`CREATE TABLE IF NOT EXISTS password_resets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			used_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// Sessions table (keyed by the SHA-256 of the session token)
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			last_seen_at DATETIME,
+			expires_at DATETIME NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
 		`CREATE TABLE IF NOT EXISTS user_preferences (
 			user_id INTEGER PRIMARY KEY,
 			practice_session_length INTEGER NOT NULL DEFAULT 10,
 			difficulty_preference TEXT NOT NULL DEFAULT 'adaptive' CHECK (difficulty_preference IN ('easy', 'medium', 'hard', 'adaptive', 'mixed')),
 			category_preference TEXT, -- JSON array or NULL for all categories
+			tag_preference TEXT, -- JSON array or NULL for any tag
 			review_mode TEXT NOT NULL DEFAULT 'immediate' CHECK (review_mode IN ('immediate', 'end_of_session')),
 			auto_advance_timing_open INTEGER NOT NULL DEFAULT 60000,
 			auto_advance_timing_choice INTEGER NOT NULL DEFAULT 30000,
@@ -79,6 +112,7 @@ func createTablesWithAuth(db *sql.DB) error {
 			theme_mode TEXT NOT NULL DEFAULT 'system' CHECK (theme_mode IN ('light', 'dark', 'system')),
 			stats_visibility BOOLEAN NOT NULL DEFAULT 1,
 			interface_language TEXT NOT NULL DEFAULT 'fr',
+			selection_mode TEXT NOT NULL DEFAULT 'smart' CHECK (selection_mode IN ('smart', 'spaced_repetition')),
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)`,
@@ -92,15 +126,23 @@ func createTablesWithAuth(db *sql.DB) error {
 			choices TEXT,
 			answer TEXT NOT NULL,
 			keywords TEXT,
+			explanation TEXT NOT NULL DEFAULT '',
+			sources TEXT,
 			difficulty TEXT NOT NULL,
 			created_by INTEGER NOT NULL DEFAULT 1,
 			status TEXT NOT NULL DEFAULT 'approved' CHECK (status IN ('pending', 'approved', 'rejected')),
 			approved_by INTEGER,
 			approved_at DATETIME,
+			assigned_to INTEGER,
+			assigned_at DATETIME,
+			import_batch_id INTEGER,
+			source_filename TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (created_by) REFERENCES users(id),
-			FOREIGN KEY (approved_by) REFERENCES users(id)
+			FOREIGN KEY (approved_by) REFERENCES users(id),
+			FOREIGN KEY (assigned_to) REFERENCES users(id),
+			FOREIGN KEY (import_batch_id) REFERENCES import_batches(id)
 		)`,
 
 		// Progress table
@@ -110,11 +152,207 @@ func createTablesWithAuth(db *sql.DB) error {
 			question_id INTEGER NOT NULL,
 			user_answer TEXT NOT NULL,
 			is_correct BOOLEAN NOT NULL,
+			score REAL, -- 0..1, partial credit for multiple_select
 			answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			time_taken_seconds INTEGER,
+			practice_session_id INTEGER,
 			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (question_id) REFERENCES questions(id),
+			FOREIGN KEY (practice_session_id) REFERENCES practice_sessions(id)
+		)`,
+
+		// Practice sessions freeze the questions served at start so answers can be reviewed at the end
+		`CREATE TABLE IF NOT EXISTS practice_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			question_ids TEXT NOT NULL, -- JSON array, in the order they were served
+			review_mode TEXT NOT NULL DEFAULT 'immediate' CHECK (review_mode IN ('immediate', 'end_of_session')),
+			status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'finished')),
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// Spaced repetition state (SM-2) of each question a user has answered
+		`CREATE TABLE IF NOT EXISTS question_schedules (
+			user_id INTEGER NOT NULL,
+			question_id INTEGER NOT NULL,
+			repetitions INTEGER NOT NULL DEFAULT 0,
+			interval_days INTEGER NOT NULL DEFAULT 0,
+			ease_factor REAL NOT NULL DEFAULT 2.5,
+			lapses INTEGER NOT NULL DEFAULT 0,
+			due_at DATETIME NOT NULL,
+			last_reviewed_at DATETIME NOT NULL,
+			PRIMARY KEY (user_id, question_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+		)`,
+
+		// Exam blueprints describe how a mock exam paper is built
+		`CREATE TABLE IF NOT EXISTS exam_blueprints (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			time_limit_minutes INTEGER NOT NULL CHECK (time_limit_minutes > 0),
+			pass_mark REAL NOT NULL CHECK (pass_mark > 0 AND pass_mark <= 1),
+			sections TEXT NOT NULL, -- JSON array of {category, difficulty, count}
+			is_default BOOLEAN NOT NULL DEFAULT 0,
+			created_by INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		)`,
+
+		// Exam attempts snapshot the blueprint rules so later edits don't change a running exam
+		`CREATE TABLE IF NOT EXISTS exam_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			blueprint_id INTEGER,
+			blueprint_name TEXT NOT NULL,
+			question_ids TEXT NOT NULL, -- JSON array, in the order they were served
+			pass_mark REAL NOT NULL,
+			status TEXT NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'submitted', 'expired')),
+			started_at DATETIME NOT NULL,
+			deadline_at DATETIME NOT NULL,
+			submitted_at DATETIME,
+			correct INTEGER,
+			score REAL,
+			passed BOOLEAN,
+			breakdown TEXT, -- JSON object of category -> {answered, correct}
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (blueprint_id) REFERENCES exam_blueprints(id) ON DELETE SET NULL
+		)`,
+
+		// Answers saved during an exam, graded only when the attempt is submitted
+		`CREATE TABLE IF NOT EXISTS exam_attempt_answers (
+			attempt_id INTEGER NOT NULL,
+			question_id INTEGER NOT NULL,
+			user_answer TEXT NOT NULL,
+			is_correct BOOLEAN,
+			answered_at DATETIME NOT NULL,
+			PRIMARY KEY (attempt_id, question_id),
+			FOREIGN KEY (attempt_id) REFERENCES exam_attempts(id) ON DELETE CASCADE,
 			FOREIGN KEY (question_id) REFERENCES questions(id)
 		)`,
+
+		// Snapshot of a question after every change, numbered per question
+		`CREATE TABLE IF NOT EXISTS question_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL,
+			revision_number INTEGER NOT NULL,
+			category TEXT NOT NULL,
+			question TEXT NOT NULL,
+			question_type TEXT NOT NULL,
+			choices TEXT,
+			answer TEXT NOT NULL,
+			keywords TEXT,
+			explanation TEXT NOT NULL DEFAULT '',
+			sources TEXT,
+			tags TEXT,
+			difficulty TEXT NOT NULL,
+			status TEXT NOT NULL,
+			change_type TEXT NOT NULL CHECK (change_type IN ('baseline', 'create', 'import', 'update', 'approve', 'reject', 'resubmit', 'rollback')),
+			changed_by INTEGER,
+			created_at DATETIME NOT NULL,
+			UNIQUE (question_id, revision_number),
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+			FOREIGN KEY (changed_by) REFERENCES users(id)
+		)`,
+
+		// Moderation decisions, a reason is mandatory on rejections
+		`CREATE TABLE IF NOT EXISTS question_reviews (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL,
+			reviewer_id INTEGER NOT NULL,
+			decision TEXT NOT NULL CHECK (decision IN ('approve', 'reject')),
+			reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+			FOREIGN KEY (reviewer_id) REFERENCES users(id)
+		)`,
+
+		// Discussion between a question's author and the moderators
+		`CREATE TABLE IF NOT EXISTS question_comments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+
+		// Asynchronous imports, the uploaded file waits on disk until the worker has gone through it
+		`CREATE TABLE IF NOT EXISTS import_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_by INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+			format TEXT NOT NULL CHECK (format IN ('json', 'csv', 'tsv')),
+			delimiter TEXT NOT NULL DEFAULT ',',
+			list_separator TEXT NOT NULL DEFAULT '|',
+			allow_near_duplicates BOOLEAN NOT NULL DEFAULT 0,
+			source_filename TEXT NOT NULL DEFAULT '',
+			file_path TEXT NOT NULL,
+			file_size INTEGER NOT NULL DEFAULT 0,
+			bytes_processed INTEGER NOT NULL DEFAULT 0,
+			processed_questions INTEGER NOT NULL DEFAULT 0,
+			imported_questions INTEGER NOT NULL DEFAULT 0,
+			skipped_questions INTEGER NOT NULL DEFAULT 0,
+			errors TEXT NOT NULL DEFAULT '[]', -- JSON array, only the first errors are kept
+			error_count INTEGER NOT NULL DEFAULT 0,
+			failure TEXT NOT NULL DEFAULT '',
+			import_batch_id INTEGER,
+			created_at DATETIME NOT NULL,
+			started_at DATETIME,
+			finished_at DATETIME,
+			FOREIGN KEY (created_by) REFERENCES users(id),
+			FOREIGN KEY (import_batch_id) REFERENCES import_batches(id)
+		)`,
+
+		// One row per import, so the questions it brought in can be traced and rolled back together
+		`CREATE TABLE IF NOT EXISTS import_batches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			imported_by INTEGER NOT NULL,
+			source_filename TEXT NOT NULL DEFAULT '',
+			format TEXT NOT NULL DEFAULT 'json',
+			status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'rolled_back')),
+			created_at DATETIME NOT NULL,
+			rolled_back_by INTEGER,
+			rolled_back_at DATETIME,
+			FOREIGN KEY (imported_by) REFERENCES users(id),
+			FOREIGN KEY (rolled_back_by) REFERENCES users(id)
+		)`,
+
+		// Question categories, questions.category holds the slug
+		`CREATE TABLE IF NOT EXISTS categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			position INTEGER NOT NULL DEFAULT 0,
+			parent_id INTEGER,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			FOREIGN KEY (parent_id) REFERENCES categories(id)
+		)`,
+
+		// Tags group questions across categories
+		`CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS question_tags (
+			question_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (question_id, tag_id),
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		)`,
 	}
 
 	for i, query := range queries {
@@ -124,13 +362,118 @@ func createTablesWithAuth(db *sql.DB) error {
 		}
 	}
 
+	// Columns added after a table was first released, applied to existing databases
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"sessions", "user_agent", "TEXT NOT NULL DEFAULT ''"},
+		{"sessions", "ip_address", "TEXT NOT NULL DEFAULT ''"},
+		{"sessions", "last_seen_at", "DATETIME"},
+		{"progress", "practice_session_id", "INTEGER REFERENCES practice_sessions(id)"},
+		{"progress", "score", "REAL"},
+		{"questions", "assigned_to", "INTEGER REFERENCES users(id)"},
+		{"questions", "assigned_at", "DATETIME"},
+		{"questions", "import_batch_id", "INTEGER REFERENCES import_batches(id)"},
+		{"questions", "source_filename", "TEXT NOT NULL DEFAULT ''"},
+		{"questions", "explanation", "TEXT NOT NULL DEFAULT ''"},
+		{"questions", "sources", "TEXT"},
+		{"question_revisions", "explanation", "TEXT NOT NULL DEFAULT ''"},
+		{"question_revisions", "sources", "TEXT"},
+		{"question_revisions", "tags", "TEXT"},
+		{"import_jobs", "import_batch_id", "INTEGER REFERENCES import_batches(id)"},
+		{"user_preferences", "tag_preference", "TEXT"},
+		{"user_preferences", "selection_mode", "TEXT NOT NULL DEFAULT 'smart' CHECK (selection_mode IN ('smart', 'spaced_repetition'))"},
+	}
+
+	for _, c := range columns {
+		if err := addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("failed to migrate %s.%s: %w", c.table, c.column, err)
+		}
+	}
+
+	// Answers recorded before partial credit existed score all or nothing
+	if _, err := db.Exec("UPDATE progress SET score = CASE WHEN is_correct THEN 1 ELSE 0 END WHERE score IS NULL"); err != nil {
+		return fmt.Errorf("failed to backfill progress scores: %w", err)
+	}
+
+	// Concurrent submits could record a practice answer twice before answers were unique per session
+	_, err := db.Exec(`
+		DELETE FROM progress
+		WHERE practice_session_id IS NOT NULL AND id NOT IN (
+			SELECT MIN(id) FROM progress WHERE practice_session_id IS NOT NULL GROUP BY practice_session_id, question_id
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to deduplicate practice answers: %w", err)
+	}
+
+	// Question lists page through the timestamps as stored, a missing one would drop the row from them
+	_, err = db.Exec(`
+		UPDATE questions
+		SET created_at = COALESCE(created_at, updated_at, CURRENT_TIMESTAMP),
+		    updated_at = COALESCE(updated_at, created_at, CURRENT_TIMESTAMP)
+		WHERE created_at IS NULL OR updated_at IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to backfill question timestamps: %w", err)
+	}
+
+	// Questions created before revisions were recorded start their history from their current state
+	_, err = db.Exec(`
+		INSERT INTO question_revisions (question_id, revision_number, category, question, question_type, choices,
+		                                answer, keywords, explanation, sources, tags, difficulty, status,
+		                                change_type, changed_by, created_at)
+		SELECT q.id, 1, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords,
+		       q.explanation, q.sources, ` + revisionTagsSnapshot + `, q.difficulty,
+		       q.status, 'baseline', q.created_by, COALESCE(q.updated_at, CURRENT_TIMESTAMP)
+		FROM questions q
+		WHERE NOT EXISTS (SELECT 1 FROM question_revisions r WHERE r.question_id = q.id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to backfill question revisions: %w", err)
+	}
+
+	// Seed the default blueprint on the official format: 40 questions in 45 minutes, 80% to pass
+	_, err = db.Exec(`
+		INSERT INTO exam_blueprints (name, description, time_limit_minutes, pass_mark, sections, is_default)
+		SELECT 'Examen civique', 'Mock exam following the official citizenship test format', 45, 0.8, '[{"count":40}]', 1
+		WHERE NOT EXISTS (SELECT 1 FROM exam_blueprints)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to seed exam blueprints: %w", err)
+	}
+
+	// Categories used to be free text on the questions, every value in use becomes a category
+	if err := seedCategories(db); err != nil {
+		return fmt.Errorf("failed to seed categories: %w", err)
+	}
+
 	// Create indexes for performance
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_questions_status ON questions(status)",
 		"CREATE INDEX IF NOT EXISTS idx_questions_created_by ON questions(created_by)",
+		"CREATE INDEX IF NOT EXISTS idx_questions_assigned_to ON questions(assigned_to)",
+		"CREATE INDEX IF NOT EXISTS idx_questions_import_batch_id ON questions(import_batch_id)",
+		"CREATE INDEX IF NOT EXISTS idx_questions_category ON questions(category)",
+		"CREATE INDEX IF NOT EXISTS idx_questions_created_at ON questions(created_at, id)",
+		"CREATE INDEX IF NOT EXISTS idx_questions_updated_at ON questions(updated_at, id)",
+		"CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id)",
+		"CREATE INDEX IF NOT EXISTS idx_question_tags_tag_id ON question_tags(tag_id)",
 		"CREATE INDEX IF NOT EXISTS idx_progress_user_id ON progress(user_id)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_progress_practice_session_question ON progress(practice_session_id, question_id)",
+		"CREATE INDEX IF NOT EXISTS idx_practice_sessions_user_id ON practice_sessions(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_question_schedules_due ON question_schedules(user_id, due_at)",
+		"CREATE INDEX IF NOT EXISTS idx_exam_attempts_user_id ON exam_attempts(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_question_reviews_question_id ON question_reviews(question_id)",
+		"CREATE INDEX IF NOT EXISTS idx_question_comments_question_id ON question_comments(question_id)",
+		"CREATE INDEX IF NOT EXISTS idx_import_jobs_created_by ON import_jobs(created_by)",
 		"CREATE INDEX IF NOT EXISTS idx_email_verifications_token ON email_verifications(token)",
 		"CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at)",
 		"CREATE INDEX IF NOT EXISTS idx_user_preferences_user_id ON user_preferences(user_id)",
 	}
 
@@ -142,3 +485,31 @@ func createTablesWithAuth(db *sql.DB) error {
 
 	return nil
 }
+
+// addColumnIfMissing adds a column to an existing table, SQLite has no ADD COLUMN IF NOT EXISTS
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	utils.LogDB("Adding column %s.%s", table, column)
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// maxDuplicateCandidates bounds how many similar questions are reported for one question
+const maxDuplicateCandidates = 5
+
+// fingerprintedQuestion is a question reduced to what duplicate detection needs
+type fingerprintedQuestion struct {
+	ID          int
+	Question    string
+	Category    string
+	Status      string
+	CreatedBy   int
+	fingerprint utils.QuestionFingerprint
+}
+
+func (fq *fingerprintedQuestion) candidate(score float64) models.DuplicateCandidate {
+	return models.DuplicateCandidate{
+		QuestionID: fq.ID,
+		Question:   fq.Question,
+		Category:   fq.Category,
+		Status:     fq.Status,
+		Score:      score,
+	}
+}
+
+// duplicateIndex holds the questions an import is checked against, exact matches on the
+// lowercased text and near matches on the fingerprint
+type duplicateIndex struct {
+	exact     map[string]bool
+	questions []fingerprintedQuestion
+}
+
+func (idx *duplicateIndex) add(fq fingerprintedQuestion) {
+	idx.exact[strings.ToLower(strings.TrimSpace(fq.Question))] = true
+	idx.questions = append(idx.questions, fq)
+}
+
+// similar returns the indexed questions reaching the duplicate threshold, best first
+func (idx *duplicateIndex) similar(fp utils.QuestionFingerprint, visible func(*fingerprintedQuestion) bool) []models.DuplicateCandidate {
+	var candidates []models.DuplicateCandidate
+	for i := range idx.questions {
+		fq := &idx.questions[i]
+		if visible != nil && !visible(fq) {
+			continue
+		}
+		if score := fp.Similarity(fq.fingerprint); score >= utils.DuplicateThreshold {
+			candidates = append(candidates, fq.candidate(score))
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].QuestionID < candidates[j].QuestionID
+	})
+	if len(candidates) > maxDuplicateCandidates {
+		candidates = candidates[:maxDuplicateCandidates]
+	}
+	return candidates
+}
+
+// loadDuplicateIndex fingerprints every question of the bank
+func (db *DB) loadDuplicateIndex() (*duplicateIndex, error) {
+	rows, err := db.Query("SELECT id, question, category, status, created_by FROM questions")
+	if err != nil {
+		utils.LogError("Failed to fetch questions for duplicate detection: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	idx := &duplicateIndex{exact: make(map[string]bool)}
+	for rows.Next() {
+		var fq fingerprintedQuestion
+		if err := rows.Scan(&fq.ID, &fq.Question, &fq.Category, &fq.Status, &fq.CreatedBy); err != nil {
+			utils.LogError("Failed to scan question for duplicate detection: %v", err)
+			return nil, err
+		}
+		fq.fingerprint = utils.NewQuestionFingerprint(fq.Question)
+		idx.add(fq)
+	}
+
+	return idx, rows.Err()
+}
+
+// FindSimilarQuestions returns the questions visible to the user that look like the given text
+func (db *DB) FindSimilarQuestions(text string, userID int, userRole string) ([]models.DuplicateCandidate, error) {
+	utils.LogDB("Looking for duplicates of %q", text)
+	start := time.Now()
+
+	idx, err := db.loadDuplicateIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	// Learners are only told about questions they could see anyway
+	var visible func(*fingerprintedQuestion) bool
+	if userRole != "admin" && userRole != "moderator" {
+		visible = func(fq *fingerprintedQuestion) bool {
+			return fq.Status == "approved" || (fq.CreatedBy == userID && (fq.Status == "pending" || fq.Status == "rejected"))
+		}
+	}
+
+	candidates := idx.similar(utils.NewQuestionFingerprint(text), visible)
+	utils.LogDB("Found %d likely duplicates among %d questions in %v", len(candidates), len(idx.questions), time.Since(start))
+	return candidates, nil
+}
+
+// GetDuplicateClusters groups the questions of the bank whose similarity reaches the threshold.
+// Only questions sharing a content word are compared: without one the score can not exceed 0.5.
+func (db *DB) GetDuplicateClusters(threshold float64) ([]models.DuplicateCluster, error) {
+	utils.LogDB("Computing duplicate clusters (threshold %.2f)", threshold)
+	start := time.Now()
+
+	if threshold <= 0.5 || threshold > 1 {
+		return nil, fmt.Errorf("invalid threshold: must be above 0.5 and at most 1")
+	}
+
+	idx, err := db.loadDuplicateIndex()
+	if err != nil {
+		return nil, err
+	}
+	questions := idx.questions
+
+	byToken := make(map[string][]int)
+	for i := range questions {
+		for _, token := range questions[i].fingerprint.Tokens() {
+			byToken[token] = append(byToken[token], i)
+		}
+	}
+
+	// Union-find over the questions, best[i] is the highest score of i with any other question
+	parent := make([]int, len(questions))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	best := make([]float64, len(questions))
+
+	// Each question is compared with the later ones sharing a token with it. seenBy[j] records the
+	// last i that queued j, so a pair is scored once without remembering every pair seen.
+	seenBy := make([]int, len(questions))
+	for i := range seenBy {
+		seenBy[i] = -1
+	}
+	comparisons := 0
+	var candidates []int
+	for i := range questions {
+		candidates = candidates[:0]
+		for _, token := range questions[i].fingerprint.Tokens() {
+			members := byToken[token]
+			// Members are in index order, so the later questions start right after i
+			for _, j := range members[sort.SearchInts(members, i+1):] {
+				if seenBy[j] != i {
+					seenBy[j] = i
+					candidates = append(candidates, j)
+				}
+			}
+		}
+
+		for _, j := range candidates {
+			comparisons++
+			score := questions[i].fingerprint.Similarity(questions[j].fingerprint)
+			if score < threshold {
+				continue
+			}
+			parent[find(i)] = find(j)
+			if score > best[i] {
+				best[i] = score
+			}
+			if score > best[j] {
+				best[j] = score
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range questions {
+		if best[i] > 0 {
+			root := find(i)
+			groups[root] = append(groups[root], i)
+		}
+	}
+
+	clusters := []models.DuplicateCluster{}
+	for _, members := range groups {
+		cluster := models.DuplicateCluster{}
+		for _, i := range members {
+			cluster.Questions = append(cluster.Questions, questions[i].candidate(best[i]))
+			if best[i] > cluster.MaxScore {
+				cluster.MaxScore = best[i]
+			}
+		}
+		sort.Slice(cluster.Questions, func(a, b int) bool {
+			return cluster.Questions[a].QuestionID < cluster.Questions[b].QuestionID
+		})
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(a, b int) bool {
+		if clusters[a].MaxScore != clusters[b].MaxScore {
+			return clusters[a].MaxScore > clusters[b].MaxScore
+		}
+		return clusters[a].Questions[0].QuestionID < clusters[b].Questions[0].QuestionID
+	})
+
+	utils.LogDB("Found %d duplicate clusters among %d questions (%d comparisons) in %v",
+		len(clusters), len(questions), comparisons, time.Since(start))
+	return clusters, nil
+}
@@ -0,0 +1,714 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// examGracePeriod absorbs network latency on answers sent right before the deadline
+const examGracePeriod = 30 * time.Second
+
+const examBlueprintColumns = `id, name, description, time_limit_minutes, pass_mark, sections, is_default, created_at, updated_at`
+
+func scanExamBlueprint(scanner interface{ Scan(...interface{}) error }) (*models.ExamBlueprint, error) {
+	var b models.ExamBlueprint
+	var sectionsJSON string
+
+	err := scanner.Scan(&b.ID, &b.Name, &b.Description, &b.TimeLimitMinutes, &b.PassMark, &sectionsJSON,
+		&b.IsDefault, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(sectionsJSON), &b.Sections); err != nil {
+		return nil, fmt.Errorf("invalid sections in blueprint %d: %w", b.ID, err)
+	}
+
+	for _, section := range b.Sections {
+		b.QuestionCount += section.Count
+	}
+
+	return &b, nil
+}
+
+// GetExamBlueprints returns all blueprints, the default one first
+func (db *DB) GetExamBlueprints() ([]models.ExamBlueprint, error) {
+	utils.LogDB("Executing query: GetExamBlueprints")
+
+	rows, err := db.Query(`SELECT ` + examBlueprintColumns + ` FROM exam_blueprints ORDER BY is_default DESC, name ASC`)
+	if err != nil {
+		utils.LogError("GetExamBlueprints failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	blueprints := []models.ExamBlueprint{}
+	for rows.Next() {
+		b, err := scanExamBlueprint(rows)
+		if err != nil {
+			utils.LogError("Failed to scan exam blueprint: %v", err)
+			return nil, err
+		}
+		blueprints = append(blueprints, *b)
+	}
+
+	return blueprints, rows.Err()
+}
+
+// GetExamBlueprint returns a blueprint by ID, or the default blueprint when id is 0
+func (db *DB) GetExamBlueprint(id int) (*models.ExamBlueprint, error) {
+	utils.LogDB("Executing query: GetExamBlueprint(%d)", id)
+
+	var row *sql.Row
+	if id == 0 {
+		row = db.QueryRow(`SELECT ` + examBlueprintColumns + ` FROM exam_blueprints ORDER BY is_default DESC, id ASC LIMIT 1`)
+	} else {
+		row = db.QueryRow(`SELECT `+examBlueprintColumns+` FROM exam_blueprints WHERE id = ?`, id)
+	}
+
+	b, err := scanExamBlueprint(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("exam blueprint not found")
+		}
+		utils.LogError("GetExamBlueprint(%d) failed: %v", id, err)
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// CreateExamBlueprint stores a new blueprint, making it the only default if requested
+func (db *DB) CreateExamBlueprint(req models.ExamBlueprintRequest, createdBy int) (*models.ExamBlueprint, error) {
+	utils.LogDB("Creating exam blueprint '%s'", req.Name)
+
+	sectionsJSON, _ := json.Marshal(req.Sections)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault {
+		if _, err := tx.Exec("UPDATE exam_blueprints SET is_default = 0"); err != nil {
+			utils.LogError("Failed to clear default exam blueprint: %v", err)
+			return nil, err
+		}
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO exam_blueprints (name, description, time_limit_minutes, pass_mark, sections, is_default, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, req.Description, req.TimeLimitMinutes, req.PassMark, string(sectionsJSON), req.IsDefault, createdBy)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("exam blueprint name already exists")
+		}
+		utils.LogError("Failed to create exam blueprint: %v", err)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit exam blueprint creation: %v", err)
+		return nil, err
+	}
+
+	utils.LogDB("Exam blueprint %d created", id)
+	return db.GetExamBlueprint(int(id))
+}
+
+// UpdateExamBlueprint replaces a blueprint. Attempts already started keep the rules they started with.
+func (db *DB) UpdateExamBlueprint(id int, req models.ExamBlueprintRequest) (*models.ExamBlueprint, error) {
+	utils.LogDB("Updating exam blueprint %d", id)
+
+	sectionsJSON, _ := json.Marshal(req.Sections)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault {
+		if _, err := tx.Exec("UPDATE exam_blueprints SET is_default = 0 WHERE id != ?", id); err != nil {
+			utils.LogError("Failed to clear default exam blueprint: %v", err)
+			return nil, err
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE exam_blueprints
+		SET name = ?, description = ?, time_limit_minutes = ?, pass_mark = ?, sections = ?, is_default = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, req.Name, req.Description, req.TimeLimitMinutes, req.PassMark, string(sectionsJSON), req.IsDefault, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("exam blueprint name already exists")
+		}
+		utils.LogError("Failed to update exam blueprint %d: %v", id, err)
+		return nil, err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("exam blueprint not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit exam blueprint update: %v", err)
+		return nil, err
+	}
+
+	return db.GetExamBlueprint(id)
+}
+
+// DeleteExamBlueprint removes a blueprint, past attempts keep its name
+func (db *DB) DeleteExamBlueprint(id int) error {
+	utils.LogDB("Deleting exam blueprint %d", id)
+
+	if _, err := db.Exec("UPDATE exam_attempts SET blueprint_id = NULL WHERE blueprint_id = ?", id); err != nil {
+		utils.LogError("Failed to detach attempts from exam blueprint %d: %v", id, err)
+		return err
+	}
+
+	result, err := db.Exec("DELETE FROM exam_blueprints WHERE id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete exam blueprint %d: %v", id, err)
+		return err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("exam blueprint not found")
+	}
+
+	return nil
+}
+
+// StartExamAttempt builds a paper from the blueprint and starts the clock
+func (db *DB) StartExamAttempt(userID int, blueprintID int) (*models.ExamAttempt, []models.Question, error) {
+	utils.LogDB("Starting exam attempt for user %d (blueprint %d)", userID, blueprintID)
+	start := time.Now()
+
+	blueprint, err := db.GetExamBlueprint(blueprintID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	questionIDs, err := db.buildExamPaper(blueprint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	questionIDsJSON, _ := json.Marshal(questionIDs)
+	startedAt := time.Now().UTC()
+	deadlineAt := startedAt.Add(time.Duration(blueprint.TimeLimitMinutes) * time.Minute)
+
+	result, err := db.Exec(`
+		INSERT INTO exam_attempts (user_id, blueprint_id, blueprint_name, question_ids, pass_mark, started_at, deadline_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, userID, blueprint.ID, blueprint.Name, string(questionIDsJSON), blueprint.PassMark, startedAt, deadlineAt)
+	if err != nil {
+		utils.LogError("Failed to create exam attempt: %v", err)
+		return nil, nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attempt, err := db.GetExamAttempt(int(id), userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	questions, err := db.GetExamAttemptQuestions(attempt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	duration := time.Since(start)
+	utils.LogDB("Exam attempt %d started with %d questions, deadline %s (%v)",
+		id, len(questionIDs), deadlineAt.Format(time.RFC3339), duration)
+
+	return attempt, questions, nil
+}
+
+// buildExamPaper draws random approved questions for each section, never the same question twice
+func (db *DB) buildExamPaper(blueprint *models.ExamBlueprint) ([]int, error) {
+	var questionIDs []int
+	selected := make(map[int]bool)
+
+	for _, section := range blueprint.Sections {
+		query := "SELECT id FROM questions WHERE status = 'approved'"
+		var args []interface{}
+
+		if section.Category != "" {
+			query += " AND category = ?"
+			args = append(args, section.Category)
+		}
+		if section.Difficulty != "" {
+			query += " AND difficulty = ?"
+			args = append(args, section.Difficulty)
+		}
+		query += " ORDER BY RANDOM()"
+
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			utils.LogError("Failed to select exam questions: %v", err)
+			return nil, err
+		}
+
+		picked := 0
+		for rows.Next() && picked < section.Count {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if selected[id] {
+				continue
+			}
+			selected[id] = true
+			questionIDs = append(questionIDs, id)
+			picked++
+		}
+		rows.Close()
+
+		if picked < section.Count {
+			return nil, fmt.Errorf("not enough approved questions for section (category '%s', difficulty '%s'): need %d, found %d",
+				section.Category, section.Difficulty, section.Count, picked)
+		}
+	}
+
+	return questionIDs, nil
+}
+
+// GetExamAttempt returns an attempt owned by the user, grading it first if its time ran out
+func (db *DB) GetExamAttempt(id int, userID int) (*models.ExamAttempt, error) {
+	attempt, err := db.loadExamAttempt(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.Status == "in_progress" && time.Now().UTC().After(attempt.DeadlineAt.Add(examGracePeriod)) {
+		utils.LogDB("Exam attempt %d ran out of time, grading saved answers", id)
+		if _, err := db.gradeExamAttempt(attempt, "expired"); err != nil {
+			return nil, err
+		}
+		return db.loadExamAttempt(id, userID)
+	}
+
+	return attempt, nil
+}
+
+func (db *DB) loadExamAttempt(id int, userID int) (*models.ExamAttempt, error) {
+	utils.LogDB("Executing query: GetExamAttempt(%d) for user %d", id, userID)
+
+	var a models.ExamAttempt
+	var questionIDsJSON string
+	var breakdownJSON sql.NullString
+	var correct sql.NullInt64
+	var score sql.NullFloat64
+	var passed sql.NullBool
+
+	err := db.QueryRow(`
+		SELECT a.id, a.user_id, a.blueprint_id, a.blueprint_name, a.question_ids, a.pass_mark, a.status,
+		       a.started_at, a.deadline_at, a.submitted_at, a.correct, a.score, a.passed, a.breakdown,
+		       (SELECT COUNT(*) FROM exam_attempt_answers WHERE attempt_id = a.id) as answered
+		FROM exam_attempts a
+		WHERE a.id = ? AND a.user_id = ?
+	`, id, userID).Scan(&a.ID, &a.UserID, &a.BlueprintID, &a.BlueprintName, &questionIDsJSON, &a.PassMark, &a.Status,
+		&a.StartedAt, &a.DeadlineAt, &a.SubmittedAt, &correct, &score, &passed, &breakdownJSON, &a.Answered)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("exam attempt not found")
+		}
+		utils.LogError("GetExamAttempt(%d) failed: %v", id, err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(questionIDsJSON), &a.QuestionIDs); err != nil {
+		utils.LogError("Failed to parse question IDs of exam attempt %d: %v", id, err)
+		return nil, err
+	}
+	a.Total = len(a.QuestionIDs)
+
+	if a.Status == "in_progress" {
+		if remaining := time.Until(a.DeadlineAt); remaining > 0 {
+			a.RemainingSeconds = int(remaining.Seconds())
+		}
+	} else {
+		if correct.Valid {
+			c := int(correct.Int64)
+			a.Correct = &c
+		}
+		if score.Valid {
+			a.Score = &score.Float64
+		}
+		if passed.Valid {
+			a.Passed = &passed.Bool
+		}
+		if breakdownJSON.Valid && breakdownJSON.String != "" {
+			json.Unmarshal([]byte(breakdownJSON.String), &a.Breakdown)
+		}
+	}
+
+	return &a, nil
+}
+
+// openExamQuestionIDs returns the questions of the user's exam attempts that can still be answered,
+// their answer keys must stay hidden until the attempt is graded
+func (db *DB) openExamQuestionIDs(userID int) (map[int]bool, error) {
+	rows, err := db.Query(`
+		SELECT question_ids FROM exam_attempts
+		WHERE user_id = ? AND status = 'in_progress' AND deadline_at > ?
+	`, userID, time.Now().UTC().Add(-examGracePeriod))
+	if err != nil {
+		utils.LogError("Failed to load open exam attempts of user %d: %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var questionIDsJSON string
+		if err := rows.Scan(&questionIDsJSON); err != nil {
+			return nil, err
+		}
+		var questionIDs []int
+		if err := json.Unmarshal([]byte(questionIDsJSON), &questionIDs); err != nil {
+			return nil, err
+		}
+		for _, id := range questionIDs {
+			ids[id] = true
+		}
+	}
+
+	return ids, rows.Err()
+}
+
+// GetUserExamAttempts lists the user's attempts, most recent first
+func (db *DB) GetUserExamAttempts(userID int) ([]models.ExamAttempt, error) {
+	utils.LogDB("Executing query: GetUserExamAttempts(%d)", userID)
+
+	rows, err := db.Query("SELECT id FROM exam_attempts WHERE user_id = ? ORDER BY started_at DESC", userID)
+	if err != nil {
+		utils.LogError("GetUserExamAttempts failed: %v", err)
+		return nil, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	attempts := []models.ExamAttempt{}
+	for _, id := range ids {
+		attempt, err := db.GetExamAttempt(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, *attempt)
+	}
+
+	return attempts, nil
+}
+
+// GetExamAttemptAnswers returns the answers saved so far, keyed by question ID
+func (db *DB) GetExamAttemptAnswers(attemptID int) (map[int]string, error) {
+	rows, err := db.Query("SELECT question_id, user_answer FROM exam_attempt_answers WHERE attempt_id = ?", attemptID)
+	if err != nil {
+		utils.LogError("Failed to load exam answers for attempt %d: %v", attemptID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := make(map[int]string)
+	for rows.Next() {
+		var questionID int
+		var answer string
+		if err := rows.Scan(&questionID, &answer); err != nil {
+			return nil, err
+		}
+		answers[questionID] = answer
+	}
+
+	return answers, rows.Err()
+}
+
+// GetExamAttemptQuestions loads the questions of an attempt in the order they were served
+func (db *DB) GetExamAttemptQuestions(attempt *models.ExamAttempt) ([]models.Question, error) {
+	questions := make([]models.Question, 0, len(attempt.QuestionIDs))
+	for _, questionID := range attempt.QuestionIDs {
+		question, err := db.GetQuestionByID(questionID)
+		if err == sql.ErrNoRows {
+			// The question was deleted after the exam started
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if question.QuestionType == "multiple_choice" || question.QuestionType == "multiple_select" {
+			question.Choices = shuffleChoices(question.Choices)
+		}
+		questions = append(questions, *question)
+	}
+	return questions, nil
+}
+
+// SaveExamAnswers stores answers without grading them. Answers arriving after the deadline are refused.
+func (db *DB) SaveExamAnswers(attemptID int, userID int, answers []models.ExamAnswer) (*models.ExamAttempt, error) {
+	utils.LogDB("Saving %d answers for exam attempt %d", len(answers), attemptID)
+
+	attempt, err := db.GetExamAttempt(attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.Status == "expired" {
+		return nil, fmt.Errorf("exam time limit has expired")
+	}
+	if attempt.Status != "in_progress" {
+		return nil, fmt.Errorf("exam attempt is already submitted")
+	}
+
+	inAttempt := make(map[int]bool, len(attempt.QuestionIDs))
+	for _, id := range attempt.QuestionIDs {
+		inAttempt[id] = true
+	}
+	for _, answer := range answers {
+		if !inAttempt[answer.QuestionID] {
+			return nil, fmt.Errorf("question %d is not part of this exam", answer.QuestionID)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, answer := range answers {
+		_, err := tx.Exec(`
+			INSERT INTO exam_attempt_answers (attempt_id, question_id, user_answer, answered_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(attempt_id, question_id) DO UPDATE SET user_answer = excluded.user_answer, answered_at = excluded.answered_at
+		`, attemptID, answer.QuestionID, answer.UserAnswer, now)
+		if err != nil {
+			utils.LogError("Failed to save exam answer: %v", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit exam answers: %v", err)
+		return nil, err
+	}
+
+	return db.GetExamAttempt(attemptID, userID)
+}
+
+// SubmitExamAttempt grades the attempt. Submitting past the deadline returns the expired
+// attempt, graded on the answers saved in time.
+func (db *DB) SubmitExamAttempt(attemptID int, userID int) (*models.ExamResult, error) {
+	utils.LogDB("Submitting exam attempt %d for user %d", attemptID, userID)
+
+	attempt, err := db.GetExamAttempt(attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.Status == "expired" {
+		return db.buildExamResult(attempt)
+	}
+
+	if attempt.Status != "in_progress" {
+		return nil, fmt.Errorf("exam attempt is already submitted")
+	}
+
+	return db.gradeExamAttempt(attempt, "submitted")
+}
+
+// GetExamResult returns the graded review of a finished attempt
+func (db *DB) GetExamResult(attemptID int, userID int) (*models.ExamResult, error) {
+	attempt, err := db.GetExamAttempt(attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.Status == "in_progress" {
+		return nil, fmt.Errorf("exam attempt is still in progress")
+	}
+
+	return db.buildExamResult(attempt)
+}
+
+func (db *DB) gradeExamAttempt(attempt *models.ExamAttempt, status string) (*models.ExamResult, error) {
+	start := time.Now()
+
+	answers, err := db.GetExamAttemptAnswers(attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := db.GetExamAttemptQuestions(attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	correct := 0
+	breakdown := make(map[string]models.CategoryStat)
+	scoreTotals := make(map[string]float64)
+	for i := range questions {
+		q := &questions[i]
+		stat := breakdown[q.Category]
+
+		if answer, ok := answers[q.ID]; ok {
+			// The pass mark is strict, partial credit only shows in the breakdown
+			evaluation := utils.EvaluateAnswer(q, answer)
+			isCorrect := evaluation.IsCorrect
+			scoreTotals[q.Category] += evaluation.Score
+			stat.Answered++
+			if isCorrect {
+				stat.Correct++
+				correct++
+			}
+			if _, err := tx.Exec("UPDATE exam_attempt_answers SET is_correct = ? WHERE attempt_id = ? AND question_id = ?",
+				isCorrect, attempt.ID, q.ID); err != nil {
+				utils.LogError("Failed to grade exam answer: %v", err)
+				return nil, err
+			}
+		}
+
+		breakdown[q.Category] = stat
+	}
+
+	for category, stat := range breakdown {
+		if stat.Answered > 0 {
+			stat.WeightedScore = scoreTotals[category] / float64(stat.Answered)
+			breakdown[category] = stat
+		}
+	}
+
+	// Deleted questions still count in the total so they can't make the exam easier
+	score := 0.0
+	if len(attempt.QuestionIDs) > 0 {
+		score = float64(correct) / float64(len(attempt.QuestionIDs))
+	}
+	passed := score >= attempt.PassMark
+	breakdownJSON, _ := json.Marshal(breakdown)
+
+	result, err := tx.Exec(`
+		UPDATE exam_attempts
+		SET status = ?, submitted_at = ?, correct = ?, score = ?, passed = ?, breakdown = ?
+		WHERE id = ? AND status = 'in_progress'
+	`, status, time.Now().UTC(), correct, score, passed, string(breakdownJSON), attempt.ID)
+	if err != nil {
+		utils.LogError("Failed to store exam result: %v", err)
+		return nil, err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("exam attempt is already submitted")
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit exam result: %v", err)
+		return nil, err
+	}
+
+	duration := time.Since(start)
+	utils.LogDB("Exam attempt %d graded (%s): %d/%d correct, passed: %t in %v",
+		attempt.ID, status, correct, len(attempt.QuestionIDs), passed, duration)
+
+	graded, err := db.loadExamAttempt(attempt.ID, attempt.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.buildExamResult(graded)
+}
+
+func (db *DB) buildExamResult(attempt *models.ExamAttempt) (*models.ExamResult, error) {
+	rows, err := db.Query(`
+		SELECT question_id, user_answer, COALESCE(is_correct, 0)
+		FROM exam_attempt_answers WHERE attempt_id = ?
+	`, attempt.ID)
+	if err != nil {
+		utils.LogError("Failed to load graded exam answers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	type gradedAnswer struct {
+		answer    string
+		isCorrect bool
+	}
+	graded := make(map[int]gradedAnswer)
+	for rows.Next() {
+		var questionID int
+		var g gradedAnswer
+		if err := rows.Scan(&questionID, &g.answer, &g.isCorrect); err != nil {
+			return nil, err
+		}
+		graded[questionID] = g
+	}
+
+	questions, err := db.GetExamAttemptQuestions(attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ExamResult{
+		Attempt: *attempt,
+		Items:   make([]models.ExamReviewItem, 0, len(questions)),
+	}
+
+	for i := range questions {
+		q := &questions[i]
+		item := models.ExamReviewItem{
+			QuestionID:    q.ID,
+			Question:      q.Question,
+			QuestionType:  q.QuestionType,
+			Category:      q.Category,
+			Difficulty:    q.Difficulty,
+			CorrectAnswer: q.DisplayAnswer(),
+			Explanation:   q.Explanation,
+			Sources:       q.Sources,
+		}
+		if g, ok := graded[q.ID]; ok {
+			item.Answered = true
+			item.UserAnswer = g.answer
+			item.IsCorrect = g.isCorrect
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// ExportQuestions walks the questions matching the filter in id order and hands each one to emit,
+// so callers can stream them out without holding the whole bank in memory. Questions are read a
+// page at a time, so no read stays open on the database while a slow client downloads. It returns
+// how many questions were emitted; an error from emit stops the walk and is returned as is.
+func (db *DB) ExportQuestions(filter models.QuestionExportFilter, emit func(*models.Question) error) (int, error) {
+	utils.LogDB("Exporting questions: %+v", filter)
+	start := time.Now()
+
+	// Rows come after this id so each page is a fresh keyset query
+	conditions := []string{"q.id > ?"}
+	args := []interface{}{0}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "q.category = ?")
+		args = append(args, filter.Category)
+	}
+
+	if filter.Difficulty != "" {
+		conditions = append(conditions, "q.difficulty = ?")
+		args = append(args, filter.Difficulty)
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "q.status = ?")
+		args = append(args, filter.Status)
+	}
+
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	count := 0
+	afterID := 0
+	for {
+		page, err := db.exportQuestionPage(where, args, afterID)
+		if err != nil {
+			return count, err
+		}
+
+		for i := range page {
+			if err := emit(&page[i]); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(page) < exportPageSize {
+			break
+		}
+		afterID = page[len(page)-1].ID
+	}
+
+	utils.LogDB("Exported %d questions in %v", count, time.Since(start))
+	return count, nil
+}
+
+// exportPageSize is how many questions each export query reads
+const exportPageSize = 500
+
+// exportQuestionPage reads the next page of an export, args[0] being replaced by the last id read
+func (db *DB) exportQuestionPage(where string, args []interface{}, afterID int) ([]models.Question, error) {
+	args = append([]interface{}{afterID}, args[1:]...)
+
+	rows, err := db.Query(`
+		SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.explanation, q.sources, q.difficulty,
+		       q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
+		       COALESCE(u.username, '')
+		FROM questions q
+		LEFT JOIN users u ON q.created_by = u.id
+		`+where+`
+		ORDER BY q.id ASC
+		LIMIT ?
+	`, append(args, exportPageSize)...)
+	if err != nil {
+		utils.LogError("ExportQuestions query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var page []models.Question
+	for rows.Next() {
+		var q models.Question
+		var keywordsJSON, sourcesJSON, choicesJSON sql.NullString
+
+		err := rows.Scan(&q.ID, &q.Category, &q.Question, &q.QuestionType, &choicesJSON, &q.Answer, &keywordsJSON,
+			&q.Explanation, &sourcesJSON, &q.Difficulty, &q.CreatedBy, &q.Status, &q.ApprovedBy, &q.ApprovedAt,
+			&q.CreatedAt, &q.UpdatedAt, &q.CreatorUsername)
+		if err != nil {
+			utils.LogError("Failed to scan exported question: %v", err)
+			return nil, err
+		}
+
+		if keywordsJSON.Valid && keywordsJSON.String != "" {
+			json.Unmarshal([]byte(keywordsJSON.String), &q.Keywords)
+		}
+
+		if sourcesJSON.Valid && sourcesJSON.String != "" {
+			json.Unmarshal([]byte(sourcesJSON.String), &q.Sources)
+		}
+
+		if choicesJSON.Valid && choicesJSON.String != "" {
+			json.Unmarshal([]byte(choicesJSON.String), &q.Choices)
+		}
+
+		page = append(page, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The page is released before its tags are read so only one read is open at a time
+	rows.Close()
+	if err := db.attachQuestionTags(page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// createImportBatch opens the batch the questions of an import are recorded under
+func createImportBatch(ex execer, importedBy int, sourceFilename, format string) (int, error) {
+	if format == "" {
+		format = "json"
+	}
+
+	result, err := ex.Exec(`
+		INSERT INTO import_batches (imported_by, source_filename, format, created_at)
+		VALUES (?, ?, ?, ?)
+	`, importedBy, sourceFilename, format, time.Now().UTC())
+	if err != nil {
+		utils.LogError("Failed to create import batch for user %d: %v", importedBy, err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	utils.LogImport("Opened import batch %d for user %d (%s %q)", id, importedBy, format, sourceFilename)
+	return int(id), nil
+}
+
+const importBatchColumns = `b.id, b.imported_by, COALESCE(u.username, ''), b.source_filename, b.format, b.status,
+	b.created_at, b.rolled_back_by, b.rolled_back_at,
+	(SELECT COUNT(*) FROM questions q WHERE q.import_batch_id = b.id),
+	(SELECT COUNT(*) FROM questions q WHERE q.import_batch_id = b.id AND q.status = 'pending'),
+	(SELECT COUNT(*) FROM questions q WHERE q.import_batch_id = b.id AND q.status = 'approved')`
+
+func scanImportBatch(scanner interface{ Scan(...interface{}) error }) (*models.ImportBatch, error) {
+	var batch models.ImportBatch
+	err := scanner.Scan(&batch.ID, &batch.ImportedBy, &batch.ImporterUsername, &batch.SourceFilename, &batch.Format,
+		&batch.Status, &batch.CreatedAt, &batch.RolledBackBy, &batch.RolledBackAt, &batch.QuestionCount,
+		&batch.PendingQuestions, &batch.ApprovedQuestions)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListImportBatches returns the import batches, newest first, optionally only those of one importer
+func (db *DB) ListImportBatches(importedBy int) ([]models.ImportBatch, error) {
+	utils.LogDB("Listing import batches (importer %d)", importedBy)
+
+	query := `SELECT ` + importBatchColumns + `
+		FROM import_batches b
+		LEFT JOIN users u ON u.id = b.imported_by`
+	var args []interface{}
+	if importedBy > 0 {
+		query += " WHERE b.imported_by = ?"
+		args = append(args, importedBy)
+	}
+	query += " ORDER BY b.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		utils.LogError("Failed to list import batches: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []models.ImportBatch{}
+	for rows.Next() {
+		batch, err := scanImportBatch(rows)
+		if err != nil {
+			utils.LogError("Failed to scan import batch: %v", err)
+			return nil, err
+		}
+		batches = append(batches, *batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// GetImportBatch returns one import batch with the number of questions still traced to it
+func (db *DB) GetImportBatch(id int) (*models.ImportBatch, error) {
+	row := db.QueryRow(`SELECT `+importBatchColumns+`
+		FROM import_batches b
+		LEFT JOIN users u ON u.id = b.imported_by
+		WHERE b.id = ?`, id)
+
+	batch, err := scanImportBatch(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("import batch not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to get import batch %d: %v", id, err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// RollbackImportBatch deletes every question an import brought in, along with the progress, schedules,
+// exam answers, revisions, reviews and comments attached to them, and marks the batch rolled back.
+// Questions edited since the import are deleted too: they still originate from the batch. A batch an
+// import job is still writing into can not be rolled back until the job is done.
+func (db *DB) RollbackImportBatch(id int, adminID int) (*models.ImportBatchRollback, error) {
+	utils.LogDB("Rolling back import batch %d by admin %d", id, adminID)
+	start := time.Now()
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.LogError("Failed to start transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM import_batches WHERE id = ?", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("import batch not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to load import batch %d: %v", id, err)
+		return nil, err
+	}
+	if status == "rolled_back" {
+		return nil, fmt.Errorf("import batch already rolled back")
+	}
+
+	// A job still importing into the batch would keep adding questions after the rollback
+	var activeJobs int
+	err = tx.QueryRow("SELECT COUNT(*) FROM import_jobs WHERE import_batch_id = ? AND status IN ('queued', 'running')", id).
+		Scan(&activeJobs)
+	if err != nil {
+		utils.LogError("Failed to check import jobs of batch %d: %v", id, err)
+		return nil, err
+	}
+	if activeJobs > 0 {
+		return nil, fmt.Errorf("import batch is still being imported")
+	}
+
+	// Foreign keys are not enforced, everything pointing at the questions goes first
+	for _, table := range []string{"progress", "question_schedules", "exam_attempt_answers", "question_revisions",
+		"question_reviews", "question_comments", "question_tags"} {
+		_, err := tx.Exec(fmt.Sprintf(
+			"DELETE FROM %s WHERE question_id IN (SELECT id FROM questions WHERE import_batch_id = ?)", table), id)
+		if err != nil {
+			utils.LogError("Failed to delete %s of import batch %d: %v", table, id, err)
+			return nil, err
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM questions WHERE import_batch_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete questions of import batch %d: %v", id, err)
+		return nil, err
+	}
+	deleted, _ := result.RowsAffected()
+
+	_, err = tx.Exec(`
+		UPDATE import_batches SET status = 'rolled_back', rolled_back_by = ?, rolled_back_at = ?
+		WHERE id = ?
+	`, adminID, time.Now().UTC(), id)
+	if err != nil {
+		utils.LogError("Failed to mark import batch %d rolled back: %v", id, err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit rollback of import batch %d: %v", id, err)
+		return nil, err
+	}
+
+	utils.LogDB("Rolled back import batch %d: %d questions deleted in %v", id, deleted, time.Since(start))
+	return &models.ImportBatchRollback{BatchID: id, DeletedQuestions: int(deleted)}, nil
+}
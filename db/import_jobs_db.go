@@ -0,0 +1,179 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// maxImportJobErrors bounds how many error messages an import job keeps, a broken file can have thousands
+const maxImportJobErrors = 1000
+
+// CreateImportJob records a queued import of an uploaded file and returns its ID
+func (db *DB) CreateImportJob(job *models.ImportJob) (int, error) {
+	utils.LogDB("Creating %s import job for user %d (%d bytes)", job.Format, job.CreatedBy, job.FileSize)
+
+	result, err := db.Exec(`
+		INSERT INTO import_jobs (created_by, format, delimiter, list_separator, allow_near_duplicates,
+		                         source_filename, file_path, file_size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.CreatedBy, job.Format, job.Delimiter, job.ListSeparator, job.AllowNearDuplicates,
+		job.SourceFilename, job.FilePath, job.FileSize, time.Now().UTC())
+	if err != nil {
+		utils.LogError("Failed to create import job: %v", err)
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetImportJob returns an import job with its progress so far
+func (db *DB) GetImportJob(id int) (*models.ImportJob, error) {
+	var job models.ImportJob
+	var errorsJSON string
+
+	err := db.QueryRow(`
+		SELECT id, created_by, status, format, delimiter, list_separator, allow_near_duplicates, source_filename,
+		       file_path, file_size, bytes_processed, processed_questions, imported_questions, skipped_questions,
+		       errors, error_count, failure, import_batch_id, created_at, started_at, finished_at
+		FROM import_jobs WHERE id = ?
+	`, id).Scan(&job.ID, &job.CreatedBy, &job.Status, &job.Format, &job.Delimiter, &job.ListSeparator,
+		&job.AllowNearDuplicates, &job.SourceFilename, &job.FilePath, &job.FileSize, &job.BytesProcessed,
+		&job.ProcessedQuestions, &job.ImportedQuestions, &job.SkippedQuestions, &errorsJSON, &job.ErrorCount,
+		&job.Failure, &job.ImportBatchID, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("import job not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to get import job %d: %v", id, err)
+		return nil, err
+	}
+
+	job.Errors = []string{}
+	json.Unmarshal([]byte(errorsJSON), &job.Errors)
+
+	switch {
+	case job.Status == "completed":
+		job.Progress = 1
+	case job.FileSize > 0:
+		job.Progress = float64(int(float64(job.BytesProcessed)/float64(job.FileSize)*1000)) / 1000
+	}
+
+	return &job, nil
+}
+
+// StartImportJob marks a job as running and returns the import batch its questions are recorded
+// under. A retried job keeps its first start time and its batch.
+func (db *DB) StartImportJob(id int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var createdBy int
+	var format, sourceFilename string
+	var batchID sql.NullInt64
+	err = tx.QueryRow("SELECT created_by, format, source_filename, import_batch_id FROM import_jobs WHERE id = ?", id).
+		Scan(&createdBy, &format, &sourceFilename, &batchID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("import job not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to load import job %d: %v", id, err)
+		return 0, err
+	}
+
+	if !batchID.Valid {
+		created, err := createImportBatch(tx, createdBy, sourceFilename, format)
+		if err != nil {
+			return 0, err
+		}
+		batchID = sql.NullInt64{Int64: int64(created), Valid: true}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE import_jobs SET status = 'running', started_at = COALESCE(started_at, ?), import_batch_id = ?
+		WHERE id = ? AND status IN ('queued', 'running')
+	`, time.Now().UTC(), batchID.Int64, id)
+	if err != nil {
+		utils.LogError("Failed to start import job %d: %v", id, err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(batchID.Int64), nil
+}
+
+// RecordImportJobChunk adds the outcome of one chunk to the job. processed and bytesProcessed are the
+// totals so far, so a retried job knows where to resume.
+func (db *DB) RecordImportJobChunk(id int, result *models.ImportResult, processed int, bytesProcessed int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var errorsJSON string
+	if err := tx.QueryRow("SELECT errors FROM import_jobs WHERE id = ?", id).Scan(&errorsJSON); err != nil {
+		utils.LogError("Failed to load errors of import job %d: %v", id, err)
+		return err
+	}
+
+	var jobErrors []string
+	json.Unmarshal([]byte(errorsJSON), &jobErrors)
+	for _, e := range result.Errors {
+		if len(jobErrors) >= maxImportJobErrors {
+			break
+		}
+		jobErrors = append(jobErrors, e)
+	}
+	updatedErrors, err := json.Marshal(jobErrors)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE import_jobs
+		SET processed_questions = ?, bytes_processed = ?, imported_questions = imported_questions + ?,
+		    skipped_questions = skipped_questions + ?, errors = ?, error_count = error_count + ?
+		WHERE id = ?
+	`, processed, bytesProcessed, result.ImportedQuestions, result.SkippedQuestions, string(updatedErrors),
+		len(result.Errors), id)
+	if err != nil {
+		utils.LogError("Failed to record progress of import job %d: %v", id, err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FinishImportJob closes a job, as failed when a failure message is given
+func (db *DB) FinishImportJob(id int, failure string) error {
+	status := "completed"
+	if failure != "" {
+		status = "failed"
+	}
+
+	_, err := db.Exec(`
+		UPDATE import_jobs SET status = ?, failure = ?, finished_at = ?
+		WHERE id = ?
+	`, status, failure, time.Now().UTC(), id)
+	if err != nil {
+		utils.LogError("Failed to finish import job %d: %v", id, err)
+		return err
+	}
+
+	utils.LogDB("Import job %d %s", id, status)
+	return nil
+}
@@ -0,0 +1,309 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// moderationClaimTTL frees questions claimed by a moderator who never came back to them
+const moderationClaimTTL = 2 * time.Hour
+
+// maxBulkDecisions bounds the size of a single bulk moderation request
+const maxBulkDecisions = 100
+
+// claimHeldByOther reports whether a live claim by someone other than userID blocks the question
+func claimHeldByOther(assignedTo sql.NullInt64, assignedAt *time.Time, userID int, now time.Time) bool {
+	if !assignedTo.Valid || int(assignedTo.Int64) == userID {
+		return false
+	}
+	return assignedAt != nil && now.Sub(*assignedAt) < moderationClaimTTL
+}
+
+// GetModerationQueue returns pending questions matching the filter, oldest first, with the total count
+func (db *DB) GetModerationQueue(filter models.ModerationQueueFilter, moderatorID int) ([]models.ModerationQueueItem, int, error) {
+	utils.LogDB("Getting moderation queue for moderator %d: %+v", moderatorID, filter)
+	start := time.Now()
+	now := time.Now().UTC()
+
+	conditions := []string{"q.status = 'pending'"}
+	var args []interface{}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "q.category = ?")
+		args = append(args, filter.Category)
+	}
+
+	if filter.CreatedBy > 0 {
+		conditions = append(conditions, "q.created_by = ?")
+		args = append(args, filter.CreatedBy)
+	}
+
+	if filter.Creator != "" {
+		conditions = append(conditions, "u.username = ?")
+		args = append(args, filter.Creator)
+	}
+
+	if filter.MinAgeHours > 0 {
+		conditions = append(conditions, "q.created_at <= ?")
+		args = append(args, now.Add(-time.Duration(filter.MinAgeHours*float64(time.Hour))))
+	}
+
+	if filter.MaxAgeHours > 0 {
+		conditions = append(conditions, "q.created_at >= ?")
+		args = append(args, now.Add(-time.Duration(filter.MaxAgeHours*float64(time.Hour))))
+	}
+
+	// A claim older than the TTL counts as unassigned
+	claimCutoff := now.Add(-moderationClaimTTL)
+	switch filter.Assignment {
+	case "unassigned":
+		conditions = append(conditions, "(q.assigned_to IS NULL OR q.assigned_at < ?)")
+		args = append(args, claimCutoff)
+	case "mine":
+		conditions = append(conditions, "q.assigned_to = ? AND q.assigned_at >= ?")
+		args = append(args, moderatorID, claimCutoff)
+	case "assigned":
+		conditions = append(conditions, "q.assigned_to IS NOT NULL AND q.assigned_at >= ?")
+		args = append(args, claimCutoff)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM questions q
+		LEFT JOIN users u ON q.created_by = u.id
+		WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		utils.LogError("Failed to count moderation queue: %v", err)
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.explanation, q.sources, q.difficulty,
+		       q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
+		       COALESCE(u.username, ''), q.assigned_to, COALESCE(a.username, ''), q.assigned_at
+		FROM questions q
+		LEFT JOIN users u ON q.created_by = u.id
+		LEFT JOIN users a ON q.assigned_to = a.id
+		WHERE `+where+`
+		ORDER BY q.created_at ASC, q.id ASC
+		LIMIT ? OFFSET ?
+	`, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		utils.LogError("GetModerationQueue query failed: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []models.ModerationQueueItem{}
+	for rows.Next() {
+		var item models.ModerationQueueItem
+		var keywordsJSON, sourcesJSON, choicesJSON sql.NullString
+		var assignedTo sql.NullInt64
+
+		err := rows.Scan(&item.ID, &item.Category, &item.Question.Question, &item.QuestionType, &choicesJSON, &item.Answer,
+			&keywordsJSON, &item.Explanation, &sourcesJSON, &item.Difficulty, &item.CreatedBy, &item.Status, &item.ApprovedBy, &item.ApprovedAt,
+			&item.CreatedAt, &item.UpdatedAt, &item.CreatorUsername, &assignedTo, &item.AssignedToUsername, &item.AssignedAt)
+		if err != nil {
+			utils.LogError("Failed to scan moderation queue row: %v", err)
+			return nil, 0, err
+		}
+
+		if keywordsJSON.Valid && keywordsJSON.String != "" {
+			json.Unmarshal([]byte(keywordsJSON.String), &item.Keywords)
+		}
+
+		if sourcesJSON.Valid && sourcesJSON.String != "" {
+			json.Unmarshal([]byte(sourcesJSON.String), &item.Sources)
+		}
+
+		if choicesJSON.Valid && choicesJSON.String != "" {
+			json.Unmarshal([]byte(choicesJSON.String), &item.Choices)
+		}
+
+		// Expired claims are reported as unassigned
+		if assignedTo.Valid && item.AssignedAt != nil && now.Sub(*item.AssignedAt) < moderationClaimTTL {
+			id := int(assignedTo.Int64)
+			item.AssignedTo = &id
+		} else {
+			item.AssignedToUsername = ""
+			item.AssignedAt = nil
+		}
+
+		item.AgeHours = float64(int(now.Sub(item.CreatedAt).Hours()*10)) / 10
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	utils.LogDB("Moderation queue: %d of %d pending questions in %v", len(items), total, time.Since(start))
+	return items, total, nil
+}
+
+// ClaimQuestion assigns a pending question to the moderator asking for it, unless someone else holds it
+func (db *DB) ClaimQuestion(questionID, moderatorID int) error {
+	return db.assignQuestion(questionID, moderatorID, moderatorID, false)
+}
+
+// AssignQuestion hands a pending question to a moderator, taking it over from any current assignee
+func (db *DB) AssignQuestion(questionID, moderatorID, assignedBy int) error {
+	var role string
+	err := db.QueryRow("SELECT role FROM users WHERE id = ? AND is_active = 1", moderatorID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("moderator not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to look up moderator %d: %v", moderatorID, err)
+		return err
+	}
+	if role != "moderator" && role != "admin" {
+		return fmt.Errorf("assignee must be a moderator or admin")
+	}
+
+	return db.assignQuestion(questionID, moderatorID, assignedBy, true)
+}
+
+func (db *DB) assignQuestion(questionID, moderatorID, assignedBy int, force bool) error {
+	utils.LogDB("Assigning question %d to moderator %d (by %d)", questionID, moderatorID, assignedBy)
+	now := time.Now().UTC()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var assignedTo sql.NullInt64
+	var assignedAt *time.Time
+	err = tx.QueryRow("SELECT status, assigned_to, assigned_at FROM questions WHERE id = ?", questionID).
+		Scan(&status, &assignedTo, &assignedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("question not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to load question %d for assignment: %v", questionID, err)
+		return err
+	}
+
+	if status != "pending" {
+		return fmt.Errorf("question is not pending approval")
+	}
+
+	if !force && claimHeldByOther(assignedTo, assignedAt, moderatorID, now) {
+		return fmt.Errorf("question is claimed by another moderator")
+	}
+
+	if _, err := tx.Exec("UPDATE questions SET assigned_to = ?, assigned_at = ? WHERE id = ?", moderatorID, now, questionID); err != nil {
+		utils.LogError("Failed to assign question %d: %v", questionID, err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseQuestion clears the assignment of a question. Only the assignee may release it unless force is set.
+func (db *DB) ReleaseQuestion(questionID, userID int, force bool) error {
+	utils.LogDB("Releasing question %d by user %d", questionID, userID)
+
+	var assignedTo sql.NullInt64
+	var assignedAt *time.Time
+	err := db.QueryRow("SELECT assigned_to, assigned_at FROM questions WHERE id = ?", questionID).Scan(&assignedTo, &assignedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("question not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to load question %d for release: %v", questionID, err)
+		return err
+	}
+
+	if !force && claimHeldByOther(assignedTo, assignedAt, userID, time.Now().UTC()) {
+		return fmt.Errorf("question is claimed by another moderator")
+	}
+
+	if _, err := db.Exec("UPDATE questions SET assigned_to = NULL, assigned_at = NULL WHERE id = ?", questionID); err != nil {
+		utils.LogError("Failed to release question %d: %v", questionID, err)
+		return err
+	}
+
+	return nil
+}
+
+// BulkModerate applies several approve/reject decisions in one transaction. Every decision is attempted
+// so the result explains each failure, but nothing is committed unless all of them succeed.
+func (db *DB) BulkModerate(reviewerID int, decisions []models.ModerationDecision) (*models.BulkModerationResult, error) {
+	utils.LogDB("Applying %d moderation decisions by user %d", len(decisions), reviewerID)
+	start := time.Now()
+
+	if len(decisions) == 0 {
+		return nil, fmt.Errorf("invalid bulk request: no decisions provided")
+	}
+	if len(decisions) > maxBulkDecisions {
+		return nil, fmt.Errorf("invalid bulk request: too many decisions (max %d)", maxBulkDecisions)
+	}
+
+	result := &models.BulkModerationResult{
+		Total:   len(decisions),
+		Results: make([]models.ModerationDecisionResult, 0, len(decisions)),
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	seen := make(map[int]bool)
+	for _, d := range decisions {
+		item := models.ModerationDecisionResult{QuestionID: d.QuestionID, Action: d.Action}
+		reason := strings.TrimSpace(d.Reason)
+
+		switch {
+		case d.Action != "approve" && d.Action != "reject":
+			item.Error = "action must be 'approve' or 'reject'"
+		case d.Action == "reject" && reason == "":
+			item.Error = "a reason is required when rejecting a question"
+		case seen[d.QuestionID]:
+			item.Error = "duplicate decision for this question"
+		default:
+			status, err := applyQuestionDecision(tx, d.QuestionID, reviewerID, d.Action == "approve", reason)
+			if err != nil {
+				item.Error = err.Error()
+			} else {
+				item.Success = true
+				item.Status = status
+			}
+		}
+		seen[d.QuestionID] = true
+
+		if item.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	if result.Failed > 0 {
+		utils.LogDB("Bulk moderation rolled back: %d of %d decisions failed", result.Failed, result.Total)
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit bulk moderation: %v", err)
+		return nil, err
+	}
+
+	result.Applied = true
+	utils.LogDB("Bulk moderation applied %d decisions in %v", result.Succeeded, time.Since(start))
+	return result, nil
+}
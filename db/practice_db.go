@@ -0,0 +1,301 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// StartPracticeSession selects the next questions for the user and freezes them in a new practice session.
+// A count of 0 uses the user's preferred practice session length.
+func (db *DB) StartPracticeSession(userID int, count int) (*models.PracticeSession, []models.Question, error) {
+	utils.LogDB("Starting practice session for user %d (%d questions)", userID, count)
+	start := time.Now()
+
+	preferences, err := db.GetUserPreferences(userID)
+	if err != nil {
+		utils.LogError("Failed to get preferences for user %d: %v", userID, err)
+		preferences = models.GetDefaultPreferences(userID)
+	}
+
+	if count <= 0 {
+		count = preferences.PracticeSessionLength
+	}
+
+	questions, err := db.GetNextQuestionsForUser(userID, count)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(questions) == 0 {
+		return nil, nil, fmt.Errorf("no questions available for practice")
+	}
+
+	questionIDs := make([]int, 0, len(questions))
+	for _, q := range questions {
+		questionIDs = append(questionIDs, q.ID)
+	}
+	questionIDsJSON, _ := json.Marshal(questionIDs)
+
+	result, err := db.Exec(`
+		INSERT INTO practice_sessions (user_id, question_ids, review_mode)
+		VALUES (?, ?, ?)
+	`, userID, string(questionIDsJSON), preferences.ReviewMode)
+	if err != nil {
+		utils.LogError("Failed to create practice session: %v", err)
+		return nil, nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		utils.LogError("Failed to get practice session LastInsertId: %v", err)
+		return nil, nil, err
+	}
+
+	session, err := db.GetPracticeSession(int(id), userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	duration := time.Since(start)
+	utils.LogDB("Practice session %d started with %d questions (review mode: %s) in %v",
+		id, len(questionIDs), session.ReviewMode, duration)
+
+	return session, questions, nil
+}
+
+// GetPracticeSession returns a practice session owned by the user
+func (db *DB) GetPracticeSession(id int, userID int) (*models.PracticeSession, error) {
+	utils.LogDB("Getting practice session %d for user %d", id, userID)
+
+	var session models.PracticeSession
+	var questionIDsJSON string
+
+	err := db.QueryRow(`
+		SELECT ps.id, ps.user_id, ps.question_ids, ps.review_mode, ps.status, ps.started_at, ps.finished_at,
+		       (SELECT COUNT(DISTINCT question_id) FROM progress WHERE practice_session_id = ps.id) as answered
+		FROM practice_sessions ps
+		WHERE ps.id = ? AND ps.user_id = ?
+	`, id, userID).Scan(&session.ID, &session.UserID, &questionIDsJSON, &session.ReviewMode, &session.Status,
+		&session.StartedAt, &session.FinishedAt, &session.Answered)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.LogDB("Practice session %d not found for user %d", id, userID)
+			return nil, fmt.Errorf("practice session not found")
+		}
+		utils.LogError("GetPracticeSession(%d) failed: %v", id, err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(questionIDsJSON), &session.QuestionIDs); err != nil {
+		utils.LogError("Failed to parse question IDs of practice session %d: %v", id, err)
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetPracticeSessionQuestions loads the frozen questions of a session, in the order they were served
+func (db *DB) GetPracticeSessionQuestions(session *models.PracticeSession) ([]models.Question, error) {
+	questions := make([]models.Question, 0, len(session.QuestionIDs))
+	for _, questionID := range session.QuestionIDs {
+		question, err := db.GetQuestionByID(questionID)
+		if err == sql.ErrNoRows {
+			// The question was deleted after the session started
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *question)
+	}
+	return questions, nil
+}
+
+// RecordPracticeAnswer records an answer to one of the session's questions
+func (db *DB) RecordPracticeAnswer(userID int, userRole string, sessionID int, req models.ProgressRequest) (*models.ProgressResult, error) {
+	utils.LogDB("Recording answer for practice session %d: user %d, question %d", sessionID, userID, req.QuestionID)
+
+	session, err := db.GetPracticeSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status != "active" {
+		return nil, fmt.Errorf("practice session is already finished")
+	}
+
+	inSession := false
+	for _, questionID := range session.QuestionIDs {
+		if questionID == req.QuestionID {
+			inSession = true
+			break
+		}
+	}
+	if !inSession {
+		return nil, fmt.Errorf("question %d is not part of this practice session", req.QuestionID)
+	}
+
+	var alreadyAnswered int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM progress WHERE practice_session_id = ? AND question_id = ?
+	`, sessionID, req.QuestionID).Scan(&alreadyAnswered)
+	if err != nil {
+		utils.LogError("Failed to check existing practice answer: %v", err)
+		return nil, err
+	}
+	if alreadyAnswered > 0 {
+		return nil, fmt.Errorf("question %d was already answered in this practice session", req.QuestionID)
+	}
+
+	req.PracticeSessionID = &sessionID
+	return db.RecordProgress(userID, userRole, req)
+}
+
+// openPracticeQuestionIDs returns the questions of the user's active end_of_session practice sessions,
+// other than the given one, whose outcome must stay hidden until the session is finished
+func (db *DB) openPracticeQuestionIDs(userID int, exceptSessionID int) (map[int]bool, error) {
+	rows, err := db.Query(`
+		SELECT question_ids FROM practice_sessions
+		WHERE user_id = ? AND status = 'active' AND review_mode = 'end_of_session' AND id != ?
+	`, userID, exceptSessionID)
+	if err != nil {
+		utils.LogError("Failed to load open practice sessions of user %d: %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var questionIDsJSON string
+		if err := rows.Scan(&questionIDsJSON); err != nil {
+			return nil, err
+		}
+		var questionIDs []int
+		if err := json.Unmarshal([]byte(questionIDsJSON), &questionIDs); err != nil {
+			return nil, err
+		}
+		for _, id := range questionIDs {
+			ids[id] = true
+		}
+	}
+
+	return ids, rows.Err()
+}
+
+// FinishPracticeSession closes the session and returns the per-question review.
+// Finishing an already finished session just returns its review again.
+func (db *DB) FinishPracticeSession(userID int, sessionID int) (*models.PracticeSessionReview, error) {
+	utils.LogDB("Finishing practice session %d for user %d", sessionID, userID)
+	start := time.Now()
+
+	_, err := db.Exec(`
+		UPDATE practice_sessions
+		SET status = 'finished', finished_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND status = 'active'
+	`, sessionID, userID)
+	if err != nil {
+		utils.LogError("Failed to finish practice session %d: %v", sessionID, err)
+		return nil, err
+	}
+
+	session, err := db.GetPracticeSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := db.buildPracticeReview(session)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Since(start)
+	utils.LogDB("Practice session %d finished: %d/%d correct in %v", sessionID, review.Correct, review.Total, duration)
+	return review, nil
+}
+
+func (db *DB) buildPracticeReview(session *models.PracticeSession) (*models.PracticeSessionReview, error) {
+	answers := make(map[int]models.Progress)
+
+	rows, err := db.Query(`
+		SELECT question_id, user_answer, is_correct, COALESCE(score, is_correct)
+		FROM progress
+		WHERE practice_session_id = ?
+		ORDER BY answered_at ASC
+	`, session.ID)
+	if err != nil {
+		utils.LogError("Failed to load practice session answers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Progress
+		if err := rows.Scan(&p.QuestionID, &p.UserAnswer, &p.IsCorrect, &p.Score); err != nil {
+			utils.LogError("Failed to scan practice answer: %v", err)
+			return nil, err
+		}
+		answers[p.QuestionID] = p
+	}
+
+	questions, err := db.GetPracticeSessionQuestions(session)
+	if err != nil {
+		return nil, err
+	}
+
+	inExam, err := db.openExamQuestionIDs(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.PracticeSessionReview{
+		Session: *session,
+		Items:   make([]models.PracticeReviewItem, 0, len(questions)),
+		Total:   len(questions),
+	}
+
+	for i := range questions {
+		q := &questions[i]
+		item := models.PracticeReviewItem{
+			QuestionID:   q.ID,
+			Question:     q.Question,
+			QuestionType: q.QuestionType,
+			Category:     q.Category,
+		}
+
+		// Questions of an exam still in progress keep their answer key hidden
+		if !inExam[q.ID] {
+			item.CorrectAnswer = q.DisplayAnswer()
+			item.Explanation = q.Explanation
+			item.Sources = q.Sources
+		}
+
+		if answer, ok := answers[q.ID]; ok {
+			item.Answered = true
+			item.UserAnswer = answer.UserAnswer
+			item.IsCorrect = answer.IsCorrect
+			item.Score = answer.Score
+			review.Answered++
+			if answer.IsCorrect {
+				review.Correct++
+			}
+		}
+
+		review.Items = append(review.Items, item)
+	}
+
+	if review.Total > 0 {
+		review.Score = float64(review.Correct) / float64(review.Total)
+	}
+
+	if session.FinishedAt != nil {
+		review.DurationSeconds = int(session.FinishedAt.Sub(session.StartedAt).Seconds())
+	}
+
+	return review, nil
+}
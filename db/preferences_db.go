@@ -15,19 +15,19 @@ func (db *DB) GetUserPreferences(userID int) (*models.UserPreferences, error) {
 	utils.LogDB("Getting preferences for user %d", userID)
 
 	var prefs models.UserPreferences
-	var categoryJSON sql.NullString
+	var categoryJSON, tagJSON sql.NullString
 
 	err := db.QueryRow(`
-		SELECT user_id, practice_session_length, difficulty_preference, category_preference,
+		SELECT user_id, practice_session_length, difficulty_preference, category_preference, tag_preference,
 		       review_mode, auto_advance_timing_open, auto_advance_timing_choice,
 		       question_randomization, skip_answered_questions, focus_weak_areas,
-		       theme_mode, stats_visibility, interface_language, updated_at
+		       theme_mode, stats_visibility, interface_language, selection_mode, updated_at
 		FROM user_preferences WHERE user_id = ?
 	`, userID).Scan(
-		&prefs.UserID, &prefs.PracticeSessionLength, &prefs.DifficultyPreference, &categoryJSON,
+		&prefs.UserID, &prefs.PracticeSessionLength, &prefs.DifficultyPreference, &categoryJSON, &tagJSON,
 		&prefs.ReviewMode, &prefs.AutoAdvanceTimingOpen, &prefs.AutoAdvanceTimingChoice,
 		&prefs.QuestionRandomization, &prefs.SkipAnsweredQuestions, &prefs.FocusWeakAreas,
-		&prefs.ThemeMode, &prefs.StatsVisibility, &prefs.InterfaceLanguage, &prefs.UpdatedAt,
+		&prefs.ThemeMode, &prefs.StatsVisibility, &prefs.InterfaceLanguage, &prefs.SelectionMode, &prefs.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -54,6 +54,13 @@ func (db *DB) GetUserPreferences(userID int) (*models.UserPreferences, error) {
 		prefs.CategoryPreference = nil // Empty means all categories
 	}
 
+	if tagJSON.Valid && tagJSON.String != "" {
+		if err := json.Unmarshal([]byte(tagJSON.String), &prefs.TagPreference); err != nil {
+			utils.LogError("Failed to parse tag JSON for user %d: %v", userID, err)
+			prefs.TagPreference = nil
+		}
+	}
+
 	return &prefs, nil
 }
 
@@ -68,12 +75,12 @@ func (db *DB) createDefaultPreferencesAtomic(userID int) (*models.UserPreference
 			user_id, practice_session_length, difficulty_preference, category_preference,
 			review_mode, auto_advance_timing_open, auto_advance_timing_choice,
 			question_randomization, skip_answered_questions, focus_weak_areas,
-			theme_mode, stats_visibility, interface_language, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			theme_mode, stats_visibility, interface_language, selection_mode, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`, userID, defaults.PracticeSessionLength, defaults.DifficultyPreference, nil,
 		defaults.ReviewMode, defaults.AutoAdvanceTimingOpen, defaults.AutoAdvanceTimingChoice,
 		defaults.QuestionRandomization, defaults.SkipAnsweredQuestions, defaults.FocusWeakAreas,
-		defaults.ThemeMode, defaults.StatsVisibility, defaults.InterfaceLanguage)
+		defaults.ThemeMode, defaults.StatsVisibility, defaults.InterfaceLanguage, defaults.SelectionMode)
 
 	if err != nil {
 		utils.LogError("Failed to create default preferences for user %d: %v", userID, err)
@@ -133,6 +140,20 @@ func (db *DB) UpdateUserPreferences(userID int, req models.UserPreferencesReques
 		}
 	}
 
+	if req.TagPreference != nil {
+		if len(*req.TagPreference) == 0 {
+			setParts = append(setParts, "tag_preference = NULL")
+		} else {
+			tagJSON, err := json.Marshal(req.TagPreference)
+			if err != nil {
+				utils.LogError("Failed to marshal tags: %v", err)
+				return nil, fmt.Errorf("invalid tag preference")
+			}
+			setParts = append(setParts, "tag_preference = ?")
+			args = append(args, string(tagJSON))
+		}
+	}
+
 	if req.ReviewMode != nil {
 		setParts = append(setParts, "review_mode = ?")
 		args = append(args, *req.ReviewMode)
@@ -178,6 +199,11 @@ func (db *DB) UpdateUserPreferences(userID int, req models.UserPreferencesReques
 		args = append(args, *req.InterfaceLanguage)
 	}
 
+	if req.SelectionMode != nil {
+		setParts = append(setParts, "selection_mode = ?")
+		args = append(args, *req.SelectionMode)
+	}
+
 	if len(setParts) == 0 {
 		utils.LogDB("No preferences to update for user %d", userID)
 		return current, nil
@@ -203,3 +229,60 @@ func (db *DB) UpdateUserPreferences(userID int, req models.UserPreferencesReques
 	// Return updated preferences
 	return db.GetUserPreferences(userID)
 }
+
+// replacePreferenceSlug swaps a category or tag slug for another one in the given JSON list column of
+// every user's preferences, or drops it when to is empty. A preference left empty goes back to NULL,
+// i.e. no filter, rather than matching nothing.
+func replacePreferenceSlug(tx *sql.Tx, column, from, to string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT user_id, %s FROM user_preferences WHERE %s IS NOT NULL", column, column))
+	if err != nil {
+		return err
+	}
+
+	updated := make(map[int][]string)
+	for rows.Next() {
+		var userID int
+		var listJSON string
+		if err := rows.Scan(&userID, &listJSON); err != nil {
+			rows.Close()
+			return err
+		}
+
+		var slugs []string
+		if err := json.Unmarshal([]byte(listJSON), &slugs); err != nil || !containsString(slugs, from) {
+			continue
+		}
+
+		kept := make([]string, 0, len(slugs))
+		for _, slug := range slugs {
+			if slug == from {
+				slug = to
+			}
+			if slug != "" && !containsString(kept, slug) {
+				kept = append(kept, slug)
+			}
+		}
+		updated[userID] = kept
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE user_preferences SET %s = ?, updated_at = CURRENT_TIMESTAMP WHERE user_id = ?", column)
+	for userID, slugs := range updated {
+		var value interface{}
+		if len(slugs) > 0 {
+			data, _ := json.Marshal(slugs)
+			value = string(data)
+		}
+		if _, err := tx.Exec(query, value, userID); err != nil {
+			return err
+		}
+	}
+
+	if len(updated) > 0 {
+		utils.LogDB("Replaced '%s' with '%s' in the %s of %d users", from, to, column, len(updated))
+	}
+	return nil
+}
@@ -1,32 +1,81 @@
 package db
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/adamspd/QuizzApi/models"
 	"github.com/adamspd/QuizzApi/utils"
 )
 
-func (db *DB) RecordProgress(userID int, req models.ProgressRequest) (*models.Progress, error) {
+// RecordProgress grades and records an answer. Only questions the user may see can be answered,
+// anything else is reported as not found.
+func (db *DB) RecordProgress(userID int, userRole string, req models.ProgressRequest) (*models.ProgressResult, error) {
 	utils.LogDB("Recording progress: user %d, question %d", userID, req.QuestionID)
 	start := time.Now()
 
+	// Grading would reveal the answer key of a question the user is still sitting an exam on
+	inExam, err := db.openExamQuestionIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	if inExam[req.QuestionID] {
+		utils.LogDB("Question %d is part of an exam in progress for user %d", req.QuestionID, userID)
+		return nil, fmt.Errorf("question %d is part of an exam in progress", req.QuestionID)
+	}
+
+	// Nor may it reveal the outcome of a practice session that only shows it once finished
+	currentSession := 0
+	if req.PracticeSessionID != nil {
+		currentSession = *req.PracticeSessionID
+	}
+	inPractice, err := db.openPracticeQuestionIDs(userID, currentSession)
+	if err != nil {
+		return nil, err
+	}
+	if inPractice[req.QuestionID] {
+		utils.LogDB("Question %d is part of a practice session in progress for user %d", req.QuestionID, userID)
+		return nil, fmt.Errorf("question %d is part of a practice session in progress, finish it first", req.QuestionID)
+	}
+
+	visible := "SELECT COUNT(*) FROM questions q WHERE q.id = ?"
+	args := []interface{}{req.QuestionID}
+	if condition, conditionArgs := questionVisibilityCondition(userID, userRole); condition != "" {
+		visible += " AND " + condition
+		args = append(args, conditionArgs...)
+	}
+	var count int
+	if err := db.QueryRow(visible, args...).Scan(&count); err != nil {
+		utils.LogError("Failed to check visibility of question %d: %v", req.QuestionID, err)
+		return nil, err
+	}
+	if count == 0 {
+		utils.LogDB("Question %d not visible to user %d (role: %s)", req.QuestionID, userID, userRole)
+		return nil, fmt.Errorf("question not found")
+	}
+
 	question, err := db.GetQuestionByID(req.QuestionID)
 	if err != nil {
 		utils.LogError("Failed to get question %d for progress check: %v", req.QuestionID, err)
 		return nil, err
 	}
 
-	isCorrect := utils.CheckAnswer(question, req.UserAnswer)
+	evaluation := utils.EvaluateAnswer(question, req.UserAnswer)
+	isCorrect := evaluation.IsCorrect
 
-	utils.LogDB("Answer check for %s question: user='%s' vs correct='%s' -> %t",
-		question.QuestionType, req.UserAnswer, question.Answer, isCorrect)
+	utils.LogDB("Answer check for %s question: user='%s' vs correct='%s' -> %t (%s, confidence %.2f)",
+		question.QuestionType, req.UserAnswer, question.Answer, isCorrect, evaluation.Reason, evaluation.Confidence)
 
 	result, err := db.Exec(`
-        INSERT INTO progress (user_id, question_id, user_answer, is_correct, time_taken_seconds)
-        VALUES (?, ?, ?, ?, ?)
-    `, userID, req.QuestionID, req.UserAnswer, isCorrect, req.TimeTakenSeconds)
+        INSERT INTO progress (user_id, question_id, user_answer, is_correct, score, time_taken_seconds, practice_session_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, userID, req.QuestionID, req.UserAnswer, isCorrect, evaluation.Score, req.TimeTakenSeconds, req.PracticeSessionID)
 
+	if err != nil && req.PracticeSessionID != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		// A concurrent submit of the same answer got there first
+		return nil, fmt.Errorf("question %d was already answered in this practice session", req.QuestionID)
+	}
 	if err != nil {
 		duration := time.Since(start)
 		utils.LogError("RecordProgress failed: %v (%v)", err, duration)
@@ -39,10 +88,27 @@ func (db *DB) RecordProgress(userID int, req models.ProgressRequest) (*models.Pr
 		return nil, err
 	}
 
+	// The answer is already recorded, a scheduling failure only delays the next review
+	if _, err := db.UpdateQuestionSchedule(userID, req.QuestionID, isCorrect); err != nil {
+		utils.LogError("Failed to reschedule question %d for user %d: %v", req.QuestionID, userID, err)
+	}
+
 	duration := time.Since(start)
-	utils.LogDB("Progress recorded with ID %d (correct: %t) in %v", id, isCorrect, duration)
+	utils.LogDB("Progress recorded with ID %d (correct: %t, score: %.2f) in %v", id, isCorrect, evaluation.Score, duration)
+
+	progress, err := db.GetProgressByID(int(id))
+	if err != nil {
+		return nil, err
+	}
 
-	return db.GetProgressByID(int(id))
+	return &models.ProgressResult{
+		Progress:      *progress,
+		CorrectAnswer: question.DisplayAnswer(),
+		Explanation:   question.Explanation,
+		Sources:       question.Sources,
+		Confidence:    evaluation.Confidence,
+		Reason:        evaluation.Reason,
+	}, nil
 }
 
 func (db *DB) GetProgressByID(id int) (*models.Progress, error) {
@@ -51,9 +117,11 @@ func (db *DB) GetProgressByID(id int) (*models.Progress, error) {
 	var p models.Progress
 
 	err := db.QueryRow(`
-        SELECT id, user_id, question_id, user_answer, is_correct, answered_at, time_taken_seconds
+        SELECT id, user_id, question_id, user_answer, is_correct, COALESCE(score, is_correct), answered_at,
+               time_taken_seconds, practice_session_id
         FROM progress WHERE id = ?
-    `, id).Scan(&p.ID, &p.UserID, &p.QuestionID, &p.UserAnswer, &p.IsCorrect, &p.AnsweredAt, &p.TimeTakenSeconds)
+    `, id).Scan(&p.ID, &p.UserID, &p.QuestionID, &p.UserAnswer, &p.IsCorrect, &p.Score, &p.AnsweredAt,
+		&p.TimeTakenSeconds, &p.PracticeSessionID)
 
 	if err != nil {
 		utils.LogError("GetProgressByID(%d) failed: %v", id, err)
@@ -80,9 +148,10 @@ func (db *DB) GetUserStats(userID int) (*models.Stats, error) {
 	// Use COALESCE to handle NULL values when a user has no progress
 	err = db.QueryRow(`
         SELECT COALESCE(COUNT(*), 0) as answered, 
-               COALESCE(SUM(CASE WHEN is_correct THEN 1 ELSE 0 END), 0) as correct
+               COALESCE(SUM(CASE WHEN is_correct THEN 1 ELSE 0 END), 0) as correct,
+               COALESCE(AVG(COALESCE(score, is_correct)), 0) as weighted_score
         FROM progress WHERE user_id = ?
-    `, userID).Scan(&stats.Answered, &stats.Correct)
+    `, userID).Scan(&stats.Answered, &stats.Correct, &stats.WeightedScore)
 	if err != nil {
 		utils.LogError("Failed to get user progress stats: %v", err)
 		return nil, err
@@ -94,11 +163,28 @@ func (db *DB) GetUserStats(userID int) (*models.Stats, error) {
 
 	stats.Streak = db.getCurrentStreak(userID)
 
+	stats.Categories, err = db.getCategoryStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Since(start)
+	utils.LogDB("Stats calculated for user %d: %d/%d correct (%.1f%%, weighted %.1f%%), streak %d, %d categories (%v)",
+		userID, stats.Correct, stats.Answered, stats.Accuracy*100, stats.WeightedScore*100, stats.Streak, len(stats.Categories), duration)
+
+	return stats, nil
+}
+
+// getCategoryStats returns the user's answered/correct counts per category
+func (db *DB) getCategoryStats(userID int) (map[string]models.CategoryStat, error) {
+	categories := make(map[string]models.CategoryStat)
+
 	// Use COALESCE here too for consistency
 	rows, err := db.Query(`
         SELECT q.category, 
                COALESCE(COUNT(*), 0) as answered,
-               COALESCE(SUM(CASE WHEN p.is_correct THEN 1 ELSE 0 END), 0) as correct
+               COALESCE(SUM(CASE WHEN p.is_correct THEN 1 ELSE 0 END), 0) as correct,
+               COALESCE(AVG(COALESCE(p.score, p.is_correct)), 0) as weighted_score
         FROM progress p
         JOIN questions q ON p.question_id = q.id
         WHERE p.user_id = ?
@@ -113,24 +199,22 @@ func (db *DB) GetUserStats(userID int) (*models.Stats, error) {
 	for rows.Next() {
 		var category string
 		var answered, correct int
+		var weightedScore float64
 
-		err := rows.Scan(&category, &answered, &correct)
+		err := rows.Scan(&category, &answered, &correct, &weightedScore)
 		if err != nil {
 			utils.LogError("Failed to scan category stats: %v", err)
 			return nil, err
 		}
 
-		stats.Categories[category] = models.CategoryStat{
-			Answered: answered,
-			Correct:  correct,
+		categories[category] = models.CategoryStat{
+			Answered:      answered,
+			Correct:       correct,
+			WeightedScore: weightedScore,
 		}
 	}
 
-	duration := time.Since(start)
-	utils.LogDB("Stats calculated for user %d: %d/%d correct (%.1f%%), streak %d, %d categories (%v)",
-		userID, stats.Correct, stats.Answered, stats.Accuracy*100, stats.Streak, len(stats.Categories), duration)
-
-	return stats, nil
+	return categories, rows.Err()
 }
 
 func (db *DB) getCurrentStreak(userID int) int {
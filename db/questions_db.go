@@ -2,9 +2,12 @@ package db
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,61 +33,216 @@ func shuffleChoices(choices []string) []string {
 	return shuffled
 }
 
-func (db *DB) GetAllQuestionsForUser(userID int, userRole string) ([]models.Question, error) {
-	utils.LogDB("Getting questions for user %d (role: %s)", userID, userRole)
+// questionSortColumns maps the sort options of GET /questions to their sort key. Columns are compared
+// as stored, timestamps being sortable text, so the (column, id) indexes serve the keyset queries.
+var questionSortColumns = map[string]string{
+	"created_at": "q.created_at",
+	"updated_at": "q.updated_at",
+	"id":         "q.id",
+	"category":   "q.category",
+	"difficulty": "CASE q.difficulty WHEN 'easy' THEN '1' WHEN 'medium' THEN '2' WHEN 'hard' THEN '3' ELSE '4' END",
+}
+
+// questionCursor is the position after the last row of a page. The sort and order are kept so a cursor
+// cannot be replayed against a different ordering, the total so later pages don't count again.
+type questionCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Key   string `json:"k"`
+	ID    int    `json:"i"`
+	Total int    `json:"t"`
+}
+
+func encodeQuestionCursor(c questionCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeQuestionCursor(value string) (*questionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c questionCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// questionVisibilityCondition restricts a query on questions q to what the user may see:
+// admins and moderators see everything, regular users approved questions + their own pending and
+// rejected ones, so contributors can find what was turned down and fix it
+func questionVisibilityCondition(userID int, userRole string) (string, []interface{}) {
+	if userRole == "admin" || userRole == "moderator" {
+		return "", nil
+	}
+	return "(q.status = 'approved' OR (q.created_by = ? AND q.status IN ('pending', 'rejected')))", []interface{}{userID}
+}
+
+// ListQuestions returns one page of the questions visible to the user. Filtering, ordering and keyset
+// pagination all happen in SQL so only the requested page is loaded.
+func (db *DB) ListQuestions(userID int, userRole string, filter models.QuestionListFilter) (*models.QuestionPage, error) {
+	utils.LogDB("Listing questions for user %d (role: %s): %+v", userID, userRole, filter)
 	start := time.Now()
 
-	var query string
+	sortColumn, ok := questionSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid filter: sort must be one of created_at, updated_at, id, category, difficulty")
+	}
+	direction, comparison := "DESC", "<"
+	if filter.Order == "asc" {
+		direction, comparison = "ASC", ">"
+	}
+
+	var conditions []string
 	var args []interface{}
 
-	if userRole == "admin" || userRole == "moderator" {
-		// Admins and moderators see all questions
-		query = `
-			SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.difficulty,
-				   q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
-				   u.username as creator_username
-			FROM questions q
-			LEFT JOIN users u ON q.created_by = u.id
-			ORDER BY q.created_at DESC
-		`
-	} else {
-		// Regular users see approved questions + their own pending questions
-		query = `
-			SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.difficulty,
-				   q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
-				   u.username as creator_username
-			FROM questions q
-			LEFT JOIN users u ON q.created_by = u.id
-			WHERE q.status = 'approved' OR (q.created_by = ? AND q.status = 'pending')
-			ORDER BY q.created_at DESC
-		`
-		args = append(args, userID)
+	if condition, conditionArgs := questionVisibilityCondition(userID, userRole); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "q.category = ?")
+		args = append(args, filter.Category)
+	}
+
+	if filter.Difficulty != "" {
+		conditions = append(conditions, "q.difficulty = ?")
+		args = append(args, filter.Difficulty)
+	}
+
+	if filter.QuestionType != "" {
+		conditions = append(conditions, "q.question_type = ?")
+		args = append(args, filter.QuestionType)
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "q.status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.CreatedBy > 0 {
+		conditions = append(conditions, "q.created_by = ?")
+		args = append(args, filter.CreatedBy)
+	}
+
+	if filter.ImportBatchID > 0 {
+		conditions = append(conditions, "q.import_batch_id = ?")
+		args = append(args, filter.ImportBatchID)
+	}
+
+	// Every requested tag must be carried by the question
+	if len(filter.Tags) > 0 {
+		tags, err := db.ResolveTags(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		filter.Tags = tags
+	}
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, questionTagCondition)
+		args = append(args, tag)
+	}
+
+	if filter.Search != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Search)
+		conditions = append(conditions, `q.question LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escaped+"%")
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// The total is counted for the first page only, later ones carry it in their cursor
+	var cursor *questionCursor
+	if filter.Cursor != "" {
+		var err error
+		if cursor, err = decodeQuestionCursor(filter.Cursor); err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return nil, fmt.Errorf("invalid cursor: it was issued for a different sort order")
+		}
+	}
+
+	var total int
+	if cursor != nil {
+		total = cursor.Total
+	} else if err := db.QueryRow("SELECT COUNT(*) FROM questions q "+where, args...).Scan(&total); err != nil {
+		utils.LogError("Failed to count questions: %v", err)
+		return nil, err
+	}
+
+	sortKey := sortColumn
+	if filter.Sort == "id" {
+		sortKey = "''"
+	}
+
+	if cursor != nil {
+		if filter.Sort == "id" {
+			conditions = append(conditions, "q.id "+comparison+" ?")
+			args = append(args, cursor.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, q.id) %s (?, ?)", sortKey, comparison))
+			args = append(args, cursor.Key, cursor.ID)
+		}
+		where = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	// Fetch one extra row to know whether another page follows
+	query := fmt.Sprintf(`
+		SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.explanation, q.sources, q.difficulty,
+			   q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
+			   COALESCE(u.username, '') as creator_username, CAST(%s AS TEXT) as sort_key
+		FROM questions q
+		LEFT JOIN users u ON q.created_by = u.id
+		%s
+		ORDER BY %s %s, q.id %s
+		LIMIT ?
+	`, sortKey, where, sortKey, direction, direction)
+	args = append(args, filter.Limit+1)
+
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		utils.LogError("GetAllQuestionsForUser query failed: %v", err)
+		utils.LogError("ListQuestions query failed: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var questions []models.Question
+	page := &models.QuestionPage{Questions: []models.Question{}, Total: total}
+	var lastKey string
 	for rows.Next() {
 		var q models.Question
-		var keywordsJSON, choicesJSON sql.NullString
+		var keywordsJSON, sourcesJSON, choicesJSON sql.NullString
+		var key string
 
 		err := rows.Scan(&q.ID, &q.Category, &q.Question, &q.QuestionType, &choicesJSON, &q.Answer, &keywordsJSON,
-			&q.Difficulty, &q.CreatedBy, &q.Status, &q.ApprovedBy, &q.ApprovedAt, &q.CreatedAt, &q.UpdatedAt,
-			&q.CreatorUsername)
+			&q.Explanation, &sourcesJSON, &q.Difficulty, &q.CreatedBy, &q.Status, &q.ApprovedBy, &q.ApprovedAt, &q.CreatedAt, &q.UpdatedAt,
+			&q.CreatorUsername, &key)
 		if err != nil {
 			utils.LogError("Failed to scan question row: %v", err)
 			return nil, err
 		}
 
+		if len(page.Questions) == filter.Limit {
+			last := page.Questions[len(page.Questions)-1]
+			page.NextCursor = encodeQuestionCursor(questionCursor{Sort: filter.Sort, Order: filter.Order, Key: lastKey, ID: last.ID, Total: total})
+			break
+		}
+
 		if keywordsJSON.Valid && keywordsJSON.String != "" {
 			json.Unmarshal([]byte(keywordsJSON.String), &q.Keywords)
 		}
 
+		if sourcesJSON.Valid && sourcesJSON.String != "" {
+			json.Unmarshal([]byte(sourcesJSON.String), &q.Sources)
+		}
+
 		if choicesJSON.Valid && choicesJSON.String != "" {
 			json.Unmarshal([]byte(choicesJSON.String), &q.Choices)
 		}
@@ -94,12 +252,21 @@ func (db *DB) GetAllQuestionsForUser(userID int, userRole string) ([]models.Ques
 			q.Choices = shuffleChoices(q.Choices)
 		}
 
-		questions = append(questions, q)
+		page.Questions = append(page.Questions, q)
+		lastKey = key
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := db.attachQuestionTags(page.Questions); err != nil {
+		return nil, err
 	}
 
 	duration := time.Since(start)
-	utils.LogDB("GetAllQuestionsForUser completed: %d questions in %v", len(questions), duration)
-	return questions, nil
+	utils.LogDB("ListQuestions completed: %d of %d questions in %v", len(page.Questions), total, duration)
+	return page, nil
 }
 
 func (db *DB) GetQuestionByID(id int) (*models.Question, error) {
@@ -107,13 +274,18 @@ func (db *DB) GetQuestionByID(id int) (*models.Question, error) {
 	start := time.Now()
 
 	var q models.Question
-	var keywordsJSON, choicesJSON sql.NullString
+	var keywordsJSON, sourcesJSON, choicesJSON, creatorUsername sql.NullString
 
 	err := db.QueryRow(`
-        SELECT id, category, question, question_type, choices, answer, keywords, difficulty, created_at, updated_at 
-        FROM questions WHERE id = ?
+        SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.explanation, q.sources, q.difficulty,
+               q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
+               u.username as creator_username
+        FROM questions q
+        LEFT JOIN users u ON q.created_by = u.id
+        WHERE q.id = ?
     `, id).Scan(&q.ID, &q.Category, &q.Question, &q.QuestionType, &choicesJSON, &q.Answer, &keywordsJSON,
-		&q.Difficulty, &q.CreatedAt, &q.UpdatedAt)
+		&q.Explanation, &sourcesJSON, &q.Difficulty, &q.CreatedBy, &q.Status, &q.ApprovedBy, &q.ApprovedAt, &q.CreatedAt, &q.UpdatedAt,
+		&creatorUsername)
 
 	if err != nil {
 		duration := time.Since(start)
@@ -129,10 +301,22 @@ func (db *DB) GetQuestionByID(id int) (*models.Question, error) {
 		json.Unmarshal([]byte(keywordsJSON.String), &q.Keywords)
 	}
 
+	if sourcesJSON.Valid && sourcesJSON.String != "" {
+		json.Unmarshal([]byte(sourcesJSON.String), &q.Sources)
+	}
+
 	if choicesJSON.Valid && choicesJSON.String != "" {
 		json.Unmarshal([]byte(choicesJSON.String), &q.Choices)
 	}
 
+	q.CreatorUsername = creatorUsername.String
+
+	tags, err := db.loadQuestionTags([]int{q.ID})
+	if err != nil {
+		return nil, err
+	}
+	q.Tags = tags[q.ID]
+
 	// Shuffle choices for multiple choice questions
 	if q.QuestionType == "multiple_choice" || q.QuestionType == "multiple_select" {
 		q.Choices = shuffleChoices(q.Choices)
@@ -143,26 +327,55 @@ func (db *DB) GetQuestionByID(id int) (*models.Question, error) {
 	return &q, nil
 }
 
-func (db *DB) CreateQuestionWithAuth(req models.QuestionRequest, createdBy int, userRole string) (*models.Question, error) {
+// CreateQuestionWithAuth stores a new question. Unless allowDuplicates is set, a question resembling
+// existing ones is refused with a *models.DuplicateQuestionError listing them.
+func (db *DB) CreateQuestionWithAuth(req models.QuestionRequest, createdBy int, userRole string, allowDuplicates bool) (*models.Question, error) {
 	utils.LogDB("Creating question by user %d (role: %s)", createdBy, userRole)
 	start := time.Now()
 
-	validTypes := []string{"open_text", "multiple_choice", "true_false", "multiple_select"}
 	questionType := strings.ToLower(strings.TrimSpace(req.QuestionType))
 	if questionType == "" {
 		questionType = "open_text"
 	}
 
-	isValidType := false
-	for _, vt := range validTypes {
-		if questionType == vt {
-			isValidType = true
-			break
-		}
+	if !utils.IsValidQuestionType(questionType) {
+		return nil, fmt.Errorf("invalid question type '%s', must be one of: %v", req.QuestionType, utils.QuestionTypes)
+	}
+
+	answer, choices, err := utils.ValidateQuestionAnswer(questionType, req.Question, req.Choices, req.Answer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid question: %w", err)
+	}
+	req.Answer = answer
+	req.Choices = choices
+
+	var explanation string
+	if req.Explanation != nil {
+		explanation = *req.Explanation
+	}
+	explanation, sources, err := utils.ValidateQuestionExplanation(explanation, req.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("invalid question: %w", err)
+	}
+
+	if req.Category, err = db.resolveQuestionCategory(req.Category); err != nil {
+		return nil, err
 	}
 
-	if !isValidType {
-		return nil, fmt.Errorf("invalid question type '%s', must be one of: %v", req.QuestionType, validTypes)
+	if req.Tags, err = db.resolveQuestionTags(req.Tags); err != nil {
+		return nil, err
+	}
+
+	if !allowDuplicates {
+		duplicates, err := db.FindSimilarQuestions(req.Question, createdBy, userRole)
+		if err != nil {
+			return nil, err
+		}
+		if len(duplicates) > 0 {
+			utils.LogDB("Question by user %d refused as a likely duplicate of question %d (%.2f)",
+				createdBy, duplicates[0].QuestionID, duplicates[0].Score)
+			return nil, &models.DuplicateQuestionError{Duplicates: duplicates}
+		}
 	}
 
 	// Set status based on user role
@@ -182,10 +395,18 @@ func (db *DB) CreateQuestionWithAuth(req models.QuestionRequest, createdBy int,
 		choicesJSON, _ = json.Marshal(req.Choices)
 	}
 
-	result, err := db.Exec(`
-		INSERT INTO questions (category, question, question_type, choices, answer, keywords, difficulty, created_by, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, req.Category, req.Question, questionType, string(choicesJSON), req.Answer, string(keywordsJSON), req.Difficulty, createdBy, status)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO questions (category, question, question_type, choices, answer, keywords, explanation, sources,
+		                       difficulty, created_by, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Category, req.Question, questionType, string(choicesJSON), req.Answer, string(keywordsJSON), explanation,
+		sourcesColumn(sources), req.Difficulty, createdBy, status)
 
 	if err != nil {
 		duration := time.Since(start)
@@ -199,6 +420,19 @@ func (db *DB) CreateQuestionWithAuth(req models.QuestionRequest, createdBy int,
 		return nil, err
 	}
 
+	if err := setQuestionTags(tx, int(id), req.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := recordQuestionRevision(tx, int(id), &createdBy, "create"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit creation of question %d: %v", id, err)
+		return nil, err
+	}
+
 	duration := time.Since(start)
 	utils.LogDB("Question created with ID %d, status '%s' by user %d in %v", id, status, createdBy, duration)
 
@@ -221,22 +455,47 @@ func (db *DB) UpdateQuestionWithAuth(id int, req models.QuestionRequest, userID
 		return nil, fmt.Errorf("insufficient permissions to edit this question")
 	}
 
-	validTypes := []string{"open_text", "multiple_choice", "true_false", "multiple_select"}
 	questionType := strings.ToLower(strings.TrimSpace(req.QuestionType))
 	if questionType == "" {
 		questionType = current.QuestionType
 	}
 
-	isValidType := false
-	for _, vt := range validTypes {
-		if questionType == vt {
-			isValidType = true
-			break
-		}
+	if !utils.IsValidQuestionType(questionType) {
+		return nil, fmt.Errorf("invalid question type '%s', must be one of: %v", req.QuestionType, utils.QuestionTypes)
 	}
 
-	if !isValidType {
-		return nil, fmt.Errorf("invalid question type '%s', must be one of: %v", req.QuestionType, validTypes)
+	answer, choices, err := utils.ValidateQuestionAnswer(questionType, req.Question, req.Choices, req.Answer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid question: %w", err)
+	}
+	req.Answer = answer
+	req.Choices = choices
+
+	// The explanation and sources are kept when the update leaves them out
+	explanation, sources := current.Explanation, current.Sources
+	if req.Explanation != nil {
+		explanation = *req.Explanation
+	}
+	if req.Sources != nil {
+		sources = req.Sources
+	}
+	if explanation, sources, err = utils.ValidateQuestionExplanation(explanation, sources); err != nil {
+		return nil, fmt.Errorf("invalid question: %w", err)
+	}
+
+	// The category is kept when the update leaves it out
+	if strings.TrimSpace(req.Category) == "" {
+		req.Category = current.Category
+	}
+	if req.Category, err = db.resolveQuestionCategory(req.Category); err != nil {
+		return nil, err
+	}
+
+	// The tags are kept when the update leaves them out, an empty list removes them
+	if req.Tags != nil {
+		if req.Tags, err = db.resolveQuestionTags(req.Tags); err != nil {
+			return nil, err
+		}
 	}
 
 	// Determine status based on user role - ignore req.Status completely
@@ -263,13 +522,20 @@ func (db *DB) UpdateQuestionWithAuth(id int, req models.QuestionRequest, userID
 		choicesJSON, _ = json.Marshal(req.Choices)
 	}
 
-	result, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
 		UPDATE questions 
 		SET category = ?, question = ?, question_type = ?, choices = ?, answer = ?, keywords = ?, 
+		    explanation = ?, sources = ?,
 		    difficulty = ?, status = ?, approved_by = ?, approved_at = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`, req.Category, req.Question, questionType, string(choicesJSON), req.Answer, string(keywordsJSON),
-		req.Difficulty, newStatus, approvedBy, approvedAt, id)
+		explanation, sourcesColumn(sources), req.Difficulty, newStatus, approvedBy, approvedAt, id)
 
 	if err != nil {
 		duration := time.Since(start)
@@ -285,7 +551,7 @@ func (db *DB) UpdateQuestionWithAuth(id int, req models.QuestionRequest, userID
 	// Clear progress if answer changed
 	if current.Answer != req.Answer {
 		utils.LogDB("Answer changed for question %d, clearing progress", id)
-		deleteResult, err := db.Exec("DELETE FROM progress WHERE question_id = ?", id)
+		deleteResult, err := tx.Exec("DELETE FROM progress WHERE question_id = ?", id)
 		if err != nil {
 			utils.LogError("Failed to clear progress for question %d: %v", id, err)
 			return nil, err
@@ -294,34 +560,66 @@ func (db *DB) UpdateQuestionWithAuth(id int, req models.QuestionRequest, userID
 		utils.LogDB("Cleared %d progress entries for question %d", progressDeleted, id)
 	}
 
+	if req.Tags != nil {
+		if err := setQuestionTags(tx, id, req.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordQuestionRevision(tx, id, &userID, "update"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit update of question %d: %v", id, err)
+		return nil, err
+	}
+
 	duration := time.Since(start)
 	utils.LogDB("UpdateQuestionWithAuth(%d) completed in %v with status '%s'", id, duration, newStatus)
 
 	return db.GetQuestionByID(id)
 }
 
+// DeleteQuestion deletes a question with everything attached to it in one transaction: progress, schedules,
+// exam answers, tags, and its review thread and revision history, which are only reachable through the
+// question and so go with it.
 func (db *DB) DeleteQuestion(id int) error {
 	utils.LogDB("Deleting question ID %d", id)
 	start := time.Now()
 
-	progressResult, err := db.Exec("DELETE FROM progress WHERE question_id = ?", id)
+	tx, err := db.Begin()
 	if err != nil {
-		utils.LogError("Failed to delete progress for question %d: %v", id, err)
+		utils.LogError("Failed to start transaction: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	progressDeleted, _ := progressResult.RowsAffected()
-	if progressDeleted > 0 {
-		utils.LogDB("Deleted %d progress entries for question %d", progressDeleted, id)
+	// Foreign keys are not enforced, everything pointing at the question goes first
+	for _, table := range []string{"progress", "question_schedules", "exam_attempt_answers", "question_revisions",
+		"question_reviews", "question_comments", "question_tags"} {
+		result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE question_id = ?", table), id)
+		if err != nil {
+			utils.LogError("Failed to delete %s of question %d: %v", table, id, err)
+			return err
+		}
+		if deleted, _ := result.RowsAffected(); deleted > 0 {
+			utils.LogDB("Deleted %d %s rows for question %d", deleted, table, id)
+		}
 	}
 
-	questionResult, err := db.Exec("DELETE FROM questions WHERE id = ?", id)
+	questionResult, err := tx.Exec("DELETE FROM questions WHERE id = ?", id)
 	if err != nil {
 		duration := time.Since(start)
 		utils.LogError("Failed to delete question %d: %v (%v)", id, err, duration)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit deletion of question %d: %v", id, err)
+		return err
+	}
+
 	rowsAffected, _ := questionResult.RowsAffected()
 	duration := time.Since(start)
 
@@ -355,6 +653,7 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 			   COALESCE(last_progress.answered_at, '1970-01-01') as last_answered,
 			   COALESCE(correct_streak.streak, 0) as streak
 		FROM questions q
+		LEFT JOIN question_schedules schedule ON schedule.question_id = q.id AND schedule.user_id = ?
 		LEFT JOIN (
 			SELECT question_id, is_correct, answered_at,
 				   ROW_NUMBER() OVER (PARTITION BY question_id ORDER BY answered_at DESC) as rn
@@ -373,7 +672,18 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 		WHERE q.status = 'approved'`
 
 	var args []interface{}
-	args = append(args, userID, userID)
+	args = append(args, userID, userID, userID)
+
+	spacedRepetition := preferences.SelectionMode == "spaced_repetition"
+
+	// Adaptive difficulty and weak area focus pick from a wider candidate pool, the review
+	// schedule takes precedence in spaced repetition
+	adaptive := preferences.DifficultyPreference == "adaptive" && !spacedRepetition
+	focusWeakAreas := preferences.FocusWeakAreas && !spacedRepetition
+	limit := count
+	if adaptive || focusWeakAreas {
+		limit = count * adaptivePoolFactor
+	}
 
 	// Apply difficulty preference filter
 	if preferences.DifficultyPreference != "adaptive" && preferences.DifficultyPreference != "mixed" {
@@ -382,11 +692,17 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 		utils.LogDB("Filtering by difficulty: %s", preferences.DifficultyPreference)
 	}
 
-	// Apply category preference filter
+	// Apply category preference filter, a category brings its sub-categories along
 	if preferences.CategoryPreference != nil && len(preferences.CategoryPreference) > 0 {
 		placeholders := strings.Repeat("?,", len(preferences.CategoryPreference))
 		placeholders = placeholders[:len(placeholders)-1] // Remove trailing comma
-		query += fmt.Sprintf(" AND q.category IN (%s)", placeholders)
+		query += fmt.Sprintf(` AND q.category IN (
+			WITH RECURSIVE selected(id, slug) AS (
+				SELECT id, slug FROM categories WHERE slug IN (%s)
+				UNION
+				SELECT c.id, c.slug FROM categories c JOIN selected s ON c.parent_id = s.id
+			)
+			SELECT slug FROM selected)`, placeholders)
 
 		for _, category := range preferences.CategoryPreference {
 			args = append(args, category)
@@ -394,8 +710,22 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 		utils.LogDB("Filtering by categories: %v", preferences.CategoryPreference)
 	}
 
-	// Apply skip answered questions filter
-	if preferences.SkipAnsweredQuestions {
+	// Apply tag preference filter, a question carrying any of the tags qualifies
+	if len(preferences.TagPreference) > 0 {
+		placeholders := strings.Repeat("?,", len(preferences.TagPreference))
+		placeholders = placeholders[:len(placeholders)-1]
+		query += fmt.Sprintf(` AND q.id IN (
+			SELECT qt.question_id FROM question_tags qt JOIN tags t ON t.id = qt.tag_id
+			WHERE t.slug IN (%s))`, placeholders)
+
+		for _, tag := range preferences.TagPreference {
+			args = append(args, tag)
+		}
+		utils.LogDB("Filtering by tags: %v", preferences.TagPreference)
+	}
+
+	// Apply skip answered questions filter, the review schedule already handles this in spaced repetition
+	if preferences.SkipAnsweredQuestions && !spacedRepetition {
 		// Skip questions answered correctly in the last 2 days
 		query += ` AND (last_progress.answered_at IS NULL 
 			OR last_progress.is_correct = 0 
@@ -404,7 +734,15 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 	}
 
 	// Apply ordering based on preferences
-	if preferences.QuestionRandomization {
+	if spacedRepetition {
+		// Due reviews first (most overdue first), then new questions, then the ones due soonest
+		query += ` ORDER BY 
+			CASE WHEN schedule.due_at <= ? THEN 0 WHEN schedule.due_at IS NULL THEN 1 ELSE 2 END,
+			schedule.due_at ASC,
+			RANDOM()`
+		args = append(args, time.Now().UTC())
+		utils.LogDB("Using spaced repetition order")
+	} else if preferences.QuestionRandomization {
 		// True randomization
 		query += " ORDER BY RANDOM()"
 		utils.LogDB("Using random question order")
@@ -418,7 +756,7 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 	}
 
 	query += " LIMIT ?"
-	args = append(args, count)
+	args = append(args, limit)
 
 	// utils.LogDB("Final query: %s", query)
 	// utils.LogDB("Query args: %v", args)
@@ -472,24 +810,35 @@ func (db *DB) GetNextQuestionsForUser(userID int, count int) ([]models.Question,
 		questions = append(questions, q)
 	}
 
+	if adaptive || focusWeakAreas {
+		questions = db.applyAdaptiveSelection(userID, questions, count, adaptive, focusWeakAreas)
+	}
+
+	if err := db.attachQuestionTags(questions); err != nil {
+		return nil, err
+	}
+
 	duration := time.Since(start)
 	utils.LogDB("GetNextQuestionsForUser completed: %d questions (%d never answered, %d incorrect) in %v",
 		len(questions), neverAnswered, incorrectAnswers, duration)
 
-	utils.LogDB("Applied preferences - Difficulty: %s, Categories: %v, Skip answered: %t, Randomize: %t",
-		preferences.DifficultyPreference, preferences.CategoryPreference,
-		preferences.SkipAnsweredQuestions, preferences.QuestionRandomization)
+	utils.LogDB("Applied preferences - Difficulty: %s, Categories: %v, Tags: %v, Skip answered: %t, Randomize: %t, Selection: %s",
+		preferences.DifficultyPreference, preferences.CategoryPreference, preferences.TagPreference,
+		preferences.SkipAnsweredQuestions, preferences.QuestionRandomization, preferences.SelectionMode)
 
 	return questions, nil
 }
 
-func (db *DB) ImportQuestions(importReq models.ImportRequest) (*models.ImportResult, error) {
-	utils.LogImport("Starting import of %d questions", len(importReq.Questions))
+// ImportQuestions stores the questions of an import under an import batch. Like created questions
+// they are approved right away only when an admin imports them, anyone else's land as pending.
+func (db *DB) ImportQuestions(importReq models.ImportRequest, importedBy int, importerRole string) (*models.ImportResult, error) {
+	utils.LogImport("Starting import of %d questions by user %d (%s)", len(importReq.Questions), importedBy, importerRole)
 	start := time.Now()
 
 	result := &models.ImportResult{
 		TotalQuestions: len(importReq.Questions),
 		Errors:         make([]string, 0),
+		DryRun:         importReq.DryRun,
 	}
 
 	// Basic validation
@@ -508,25 +857,111 @@ func (db *DB) ImportQuestions(importReq models.ImportRequest) (*models.ImportRes
 	}
 	defer tx.Rollback()
 
-	// Prepare statement
-	stmt, err := db.prepareImportStatement(tx)
+	status := "pending"
+	if importerRole == "admin" {
+		status = "approved"
+	}
+
+	// Chunks of an asynchronous import share the batch of their job
+	batchID := importReq.BatchID
+	newBatch := batchID == 0
+	if newBatch {
+		if batchID, err = createImportBatch(tx, importedBy, importReq.SourceFilename, importReq.Format); err != nil {
+			return nil, err
+		}
+	} else {
+		var batchStatus string
+		err := tx.QueryRow("SELECT status FROM import_batches WHERE id = ?", batchID).Scan(&batchStatus)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("import batch not found")
+		}
+		if err != nil {
+			utils.LogError("Failed to load import batch %d: %v", batchID, err)
+			return nil, err
+		}
+		if batchStatus == "rolled_back" {
+			return nil, fmt.Errorf("import batch %d was rolled back", batchID)
+		}
+	}
+
+	// Prepare statements
+	stmts, err := db.prepareImportStatements(tx)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
+	defer stmts.Close()
+
+	target := importTarget{
+		createdBy:      importedBy,
+		status:         status,
+		batchID:        batchID,
+		sourceFilename: importReq.SourceFilename,
+	}
 
 	// Get existing questions for duplicate check
-	existingQuestions, err := db.getExistingQuestions()
+	duplicates, err := db.loadDuplicateIndex()
+	if err != nil {
+		return nil, err
+	}
+	utils.LogImport("Found %d existing questions to check for duplicates", len(duplicates.questions))
+
+	// Imported questions must use known categories, a typo would otherwise create a new one
+	categories, err := db.loadCategoryIndex()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := db.loadTagIndex()
 	if err != nil {
 		return nil, err
 	}
 
 	// Process each question
 	for i, q := range importReq.Questions {
-		if err := db.processQuestion(i+1, q, stmt, existingQuestions, result); err != nil {
+		errorCount := len(result.Errors)
+		questionNum := importReq.Offset + i + 1
+		stored, err := db.processQuestion(questionNum, q, stmts, target, categories, tags, duplicates, importReq.AllowNearDuplicates, result)
+		if !importReq.DryRun {
 			// Error already logged and added to result
 			continue
 		}
+
+		item := models.ImportReportItem{QuestionNumber: questionNum, Row: q.Row}
+		switch {
+		case err == nil:
+			item.Outcome = "imported"
+			item.Stored = stored
+		case errors.Is(err, errDuplicateImport):
+			item.Outcome = "skipped"
+		default:
+			item.Outcome = "rejected"
+		}
+		if len(result.Errors) > errorCount {
+			item.Error = result.Errors[len(result.Errors)-1]
+		}
+		result.Report = append(result.Report, item)
+	}
+
+	// A dry run stops here, the deferred rollback discards every insert
+	if importReq.DryRun {
+		result.TimeTaken = time.Since(start).String()
+		utils.LogImport("Dry run completed: %d would be imported, %d skipped or rejected in %v",
+			result.ImportedQuestions, result.SkippedQuestions, time.Since(start))
+		return result, nil
+	}
+
+	// Every question inserted above is the only one without history yet
+	if err := recordImportRevisions(tx, importedBy); err != nil {
+		return nil, err
+	}
+
+	// An import that stored nothing leaves no batch behind
+	if newBatch && result.ImportedQuestions == 0 {
+		if _, err := tx.Exec("DELETE FROM import_batches WHERE id = ?", batchID); err != nil {
+			utils.LogError("Failed to drop empty import batch %d: %v", batchID, err)
+			return nil, err
+		}
+	} else {
+		result.BatchID = batchID
 	}
 
 	// Commit transaction
@@ -538,126 +973,237 @@ func (db *DB) ImportQuestions(importReq models.ImportRequest) (*models.ImportRes
 	duration := time.Since(start)
 	result.TimeTaken = duration.String()
 
-	utils.LogImport("Import completed: %d imported, %d skipped, %d errors in %v",
-		result.ImportedQuestions, result.SkippedQuestions, len(result.Errors), duration)
+	utils.LogImport("Import completed: %d imported as %s in batch %d, %d skipped, %d errors in %v",
+		result.ImportedQuestions, status, result.BatchID, result.SkippedQuestions, len(result.Errors), duration)
 
 	return result, nil
 }
 
-func (db *DB) prepareImportStatement(tx *sql.Tx) (*sql.Stmt, error) {
-	stmt, err := tx.Prepare(`
-		INSERT INTO questions (category, question, question_type, choices, answer, keywords, difficulty)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+// importTarget is what every question of an import is recorded with besides its own fields
+type importTarget struct {
+	createdBy      int
+	status         string
+	batchID        int
+	sourceFilename string
+}
+
+// importStatements are the statements an import inserts each question and its tags with
+type importStatements struct {
+	question *sql.Stmt
+	tag      *sql.Stmt
+}
+
+func (s *importStatements) Close() {
+	s.question.Close()
+	s.tag.Close()
+}
+
+func (db *DB) prepareImportStatements(tx *sql.Tx) (*importStatements, error) {
+	question, err := tx.Prepare(`
+		INSERT INTO questions (category, question, question_type, choices, answer, keywords, explanation, sources,
+		                       difficulty, created_by, status, import_batch_id, source_filename)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		utils.LogError("Failed to prepare statement: %v", err)
 		return nil, err
 	}
-	return stmt, nil
-}
 
-func (db *DB) getExistingQuestions() (map[string]bool, error) {
-	existingQuestions := make(map[string]bool)
-	rows, err := db.Query("SELECT question FROM questions")
+	tag, err := tx.Prepare("INSERT OR IGNORE INTO question_tags (question_id, tag_id) SELECT ?, id FROM tags WHERE slug = ?")
 	if err != nil {
-		utils.LogError("Failed to fetch existing questions: %v", err)
+		question.Close()
+		utils.LogError("Failed to prepare tag statement: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var existingQuestion string
-		if err := rows.Scan(&existingQuestion); err != nil {
-			utils.LogError("Failed to scan existing question: %v", err)
-			continue
-		}
-		existingQuestions[strings.ToLower(strings.TrimSpace(existingQuestion))] = true
+	return &importStatements{question: question, tag: tag}, nil
+}
+
+// sourcesColumn stores the sources of a question as a JSON array, NULL when there are none
+func sourcesColumn(sources []string) interface{} {
+	if len(sources) == 0 {
+		return nil
 	}
+	data, _ := json.Marshal(sources)
+	return string(data)
+}
 
-	utils.LogImport("Found %d existing questions to check for duplicates", len(existingQuestions))
-	return existingQuestions, nil
+// errDuplicateImport marks an imported question skipped as a duplicate rather than rejected as invalid
+var errDuplicateImport = errors.New("duplicate question")
+
+// importItemLabel names a question in import errors, by its spreadsheet row for CSV imports
+func importItemLabel(questionNum int, q models.QuestionImport) string {
+	if q.Row > 0 {
+		return fmt.Sprintf("Row %d", q.Row)
+	}
+	return fmt.Sprintf("Question %d", questionNum)
 }
 
-func (db *DB) processQuestion(questionNum int, q models.QuestionImport, stmt *sql.Stmt, existingQuestions map[string]bool, result *models.ImportResult) error {
+func (db *DB) processQuestion(questionNum int, q models.QuestionImport, stmts *importStatements, target importTarget, categories, tags *slugIndex, duplicates *duplicateIndex, allowNearDuplicates bool, result *models.ImportResult) (*models.QuestionRequest, error) {
 	utils.LogImport("Processing question %d/%d: category='%s'", questionNum, result.TotalQuestions, q.Category)
 
 	// Basic validation
 	if err := db.validateBasicFields(questionNum, q, result); err != nil {
-		return err
+		return nil, err
+	}
+
+	category, ok := categories.resolve(q.Category)
+	if !ok {
+		errMsg := fmt.Sprintf("%s: unknown category '%s'", importItemLabel(questionNum, q), strings.TrimSpace(q.Category))
+		utils.LogImport("SKIP: %s", errMsg)
+		result.Errors = append(result.Errors, errMsg)
+		result.SkippedQuestions++
+		return nil, fmt.Errorf("unknown category")
+	}
+	q.Category = category
+
+	tagSlugs, unknownTag := resolveTagList(tags, q.Tags)
+	if unknownTag != "" {
+		errMsg := fmt.Sprintf("%s: unknown tag '%s'", importItemLabel(questionNum, q), unknownTag)
+		utils.LogImport("SKIP: %s", errMsg)
+		result.Errors = append(result.Errors, errMsg)
+		result.SkippedQuestions++
+		return nil, fmt.Errorf("unknown tag")
 	}
 
 	// Validate and normalize question type
 	questionType, err := db.validateQuestionType(questionNum, q, result)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Validate choices for multiple choice/select questions
-	if err := db.validateChoices(questionNum, q, questionType, result); err != nil {
-		return err
+	// Process and validate answer (and choices) for the question type
+	rawAnswer, err := db.processAnswer(questionNum, q, result)
+	if err != nil {
+		return nil, err
 	}
 
-	// Process and validate answer
-	finalAnswer, err := db.processAnswer(questionNum, q, questionType, result)
+	finalAnswer, err := db.validateAnswer(questionNum, &q, questionType, rawAnswer, result)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Validate difficulty
 	difficulty, err := db.validateDifficulty(questionNum, q, result)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	explanation, sources, err := utils.ValidateQuestionExplanation(q.Explanation, q.Sources)
+	if err != nil {
+		errMsg := fmt.Sprintf("%s: %v", importItemLabel(questionNum, q), err)
+		utils.LogImport("SKIP: %s", errMsg)
+		result.Errors = append(result.Errors, errMsg)
+		result.SkippedQuestions++
+		return nil, err
 	}
 
 	// Check for duplicates
 	questionKey := strings.ToLower(strings.TrimSpace(q.Question))
-	if existingQuestions[questionKey] {
-		errMsg := fmt.Sprintf("Question %d: duplicate question already exists", questionNum)
+	if duplicates.exact[questionKey] {
+		errMsg := fmt.Sprintf("%s: duplicate question already exists", importItemLabel(questionNum, q))
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
-		return fmt.Errorf("duplicate")
+		return nil, errDuplicateImport
+	}
+
+	fingerprint := utils.NewQuestionFingerprint(q.Question)
+	if !allowNearDuplicates {
+		if matches := duplicates.similar(fingerprint, nil); len(matches) > 0 {
+			errMsg := fmt.Sprintf("%s: likely duplicate of question %d (similarity %.2f)",
+				importItemLabel(questionNum, q), matches[0].QuestionID, matches[0].Score)
+			utils.LogImport("SKIP: %s", errMsg)
+			result.Errors = append(result.Errors, errMsg)
+			result.NearDuplicates = append(result.NearDuplicates, models.ImportDuplicate{
+				QuestionNumber: questionNum,
+				Row:            q.Row,
+				Question:       strings.TrimSpace(q.Question),
+				Matches:        matches,
+			})
+			result.SkippedQuestions++
+			return nil, errDuplicateImport
+		}
 	}
 
 	// Marshal JSON fields
 	keywordsJSON, choicesJSON, err := db.marshalJSONFields(questionNum, q, result)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Insert into database
-	_, err = stmt.Exec(
+	insertResult, err := stmts.question.Exec(
 		strings.TrimSpace(q.Category),
 		strings.TrimSpace(q.Question),
 		questionType,
 		string(choicesJSON),
 		finalAnswer,
 		string(keywordsJSON),
+		explanation,
+		sourcesColumn(sources),
 		difficulty,
+		target.createdBy,
+		target.status,
+		target.batchID,
+		target.sourceFilename,
 	)
 
 	if err != nil {
-		errMsg := fmt.Sprintf("Question %d: database insert failed: %v", questionNum, err)
+		errMsg := fmt.Sprintf("%s: database insert failed: %v", importItemLabel(questionNum, q), err)
 		utils.LogError("%s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
-		return err
+		return nil, err
 	}
 
-	// Success!
-	existingQuestions[questionKey] = true
+	id, _ := insertResult.LastInsertId()
+	for _, slug := range tagSlugs {
+		if _, err := stmts.tag.Exec(id, slug); err != nil {
+			errMsg := fmt.Sprintf("%s: database insert of tag '%s' failed: %v", importItemLabel(questionNum, q), slug, err)
+			utils.LogError("%s", errMsg)
+			result.Errors = append(result.Errors, errMsg)
+			result.SkippedQuestions++
+			return nil, err
+		}
+	}
+
+	// Success! Later questions of the same import are checked against this one too
+	duplicates.add(fingerprintedQuestion{
+		ID:          int(id),
+		Question:    strings.TrimSpace(q.Question),
+		Category:    strings.TrimSpace(q.Category),
+		Status:      target.status,
+		CreatedBy:   target.createdBy,
+		fingerprint: fingerprint,
+	})
 	result.ImportedQuestions++
 
 	if questionNum%10 == 0 || questionNum == result.TotalQuestions {
 		utils.LogImport("Progress: %d/%d questions processed", questionNum, result.TotalQuestions)
 	}
 
-	return nil
+	stored := &models.QuestionRequest{
+		Category:     strings.TrimSpace(q.Category),
+		Question:     strings.TrimSpace(q.Question),
+		QuestionType: questionType,
+		Choices:      q.Choices,
+		Answer:       finalAnswer,
+		Keywords:     q.Keywords,
+		Tags:         tagSlugs,
+		Sources:      sources,
+		Difficulty:   difficulty,
+		Status:       target.status,
+	}
+	if explanation != "" {
+		stored.Explanation = &explanation
+	}
+	return stored, nil
 }
 
 func (db *DB) validateBasicFields(questionNum int, q models.QuestionImport, result *models.ImportResult) error {
 	if strings.TrimSpace(q.Question) == "" {
-		errMsg := fmt.Sprintf("Question %d: empty question text", questionNum)
+		errMsg := fmt.Sprintf("%s: empty question text", importItemLabel(questionNum, q))
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
@@ -665,7 +1211,7 @@ func (db *DB) validateBasicFields(questionNum int, q models.QuestionImport, resu
 	}
 
 	if q.Answer == nil || q.Answer == "" {
-		errMsg := fmt.Sprintf("Question %d: empty answer", questionNum)
+		errMsg := fmt.Sprintf("%s: empty answer", importItemLabel(questionNum, q))
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
@@ -673,7 +1219,7 @@ func (db *DB) validateBasicFields(questionNum int, q models.QuestionImport, resu
 	}
 
 	if strings.TrimSpace(q.Category) == "" {
-		errMsg := fmt.Sprintf("Question %d: empty category", questionNum)
+		errMsg := fmt.Sprintf("%s: empty category", importItemLabel(questionNum, q))
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
@@ -687,89 +1233,29 @@ func (db *DB) validateQuestionType(questionNum int, q models.QuestionImport, res
 	questionType := strings.ToLower(strings.TrimSpace(q.QuestionType))
 	if questionType == "" {
 		questionType = "open_text"
-		utils.LogImport("Question %d: using default question type 'open_text'", questionNum)
+		utils.LogImport("%s: using default question type 'open_text'", importItemLabel(questionNum, q))
 	}
 
-	validTypes := []string{"open_text", "multiple_choice", "true_false", "multiple_select"}
-	for _, vt := range validTypes {
-		if questionType == vt {
-			return questionType, nil
-		}
+	if utils.IsValidQuestionType(questionType) {
+		return questionType, nil
 	}
 
-	errMsg := fmt.Sprintf("Question %d: invalid question type '%s', must be one of: %v", questionNum, q.QuestionType, validTypes)
+	errMsg := fmt.Sprintf("%s: invalid question type '%s', must be one of: %v", importItemLabel(questionNum, q), q.QuestionType, utils.QuestionTypes)
 	utils.LogImport("SKIP: %s", errMsg)
 	result.Errors = append(result.Errors, errMsg)
 	result.SkippedQuestions++
 	return "", fmt.Errorf("invalid question type")
 }
 
-func (db *DB) validateChoices(questionNum int, q models.QuestionImport, questionType string, result *models.ImportResult) error {
-	if (questionType == "multiple_choice" || questionType == "multiple_select") && len(q.Choices) < 2 {
-		errMsg := fmt.Sprintf("Question %d: %s questions must have at least 2 choices", questionNum, questionType)
-		utils.LogImport("SKIP: %s", errMsg)
-		result.Errors = append(result.Errors, errMsg)
-		result.SkippedQuestions++
-		return fmt.Errorf("insufficient choices")
-	}
-	return nil
-}
-
-func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionType string, result *models.ImportResult) (string, error) {
+// processAnswer turns the imported answer into its stored string form, arrays and objects become JSON
+func (db *DB) processAnswer(questionNum int, q models.QuestionImport, result *models.ImportResult) (string, error) {
 	switch answerValue := q.Answer.(type) {
 	case string:
-		answer := strings.TrimSpace(answerValue)
-
-		// For multiple_select, try to parse as JSON array first
-		if questionType == "multiple_select" {
-			if strings.HasPrefix(answer, "[") && strings.HasSuffix(answer, "]") {
-				// Already JSON, validate it
-				var testArray []string
-				if err := json.Unmarshal([]byte(answer), &testArray); err != nil {
-					errMsg := fmt.Sprintf("Question %d: invalid JSON array in answer: %v", questionNum, err)
-					utils.LogImport("SKIP: %s", errMsg)
-					result.Errors = append(result.Errors, errMsg)
-					result.SkippedQuestions++
-					return "", err
-				}
-				return answer, nil
-			} else {
-				// Comma-separated, convert to JSON
-				answers := strings.Split(answer, ",")
-				for i, a := range answers {
-					answers[i] = strings.TrimSpace(a)
-				}
-				answerJSON, err := json.Marshal(answers)
-				if err != nil {
-					errMsg := fmt.Sprintf("Question %d: failed to marshal comma-separated answer: %v", questionNum, err)
-					utils.LogImport("SKIP: %s", errMsg)
-					result.Errors = append(result.Errors, errMsg)
-					result.SkippedQuestions++
-					return "", err
-				}
-				return string(answerJSON), nil
-			}
-		}
+		return strings.TrimSpace(answerValue), nil
 
-		// For multiple_choice, validate answer is in choices
-		if questionType == "multiple_choice" {
-			answerInChoices := false
-			for _, choice := range q.Choices {
-				if utils.NormalizeAnswer(choice) == utils.NormalizeAnswer(answer) {
-					answerInChoices = true
-					break
-				}
-			}
-			if !answerInChoices {
-				errMsg := fmt.Sprintf("Question %d: answer '%s' not found in choices", questionNum, answer)
-				utils.LogImport("SKIP: %s", errMsg)
-				result.Errors = append(result.Errors, errMsg)
-				result.SkippedQuestions++
-				return "", fmt.Errorf("answer not in choices")
-			}
-		}
-
-		return answer, nil
+	case float64:
+		// Numeric answers can be given as plain JSON numbers
+		return strconv.FormatFloat(answerValue, 'f', -1, 64), nil
 
 	case []interface{}:
 		// Convert []interface{} to []string
@@ -778,7 +1264,7 @@ func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionTy
 			if str, ok := v.(string); ok {
 				answers = append(answers, str)
 			} else {
-				errMsg := fmt.Sprintf("Question %d: all answer array elements must be strings", questionNum)
+				errMsg := fmt.Sprintf("%s: all answer array elements must be strings", importItemLabel(questionNum, q))
 				utils.LogImport("SKIP: %s", errMsg)
 				result.Errors = append(result.Errors, errMsg)
 				result.SkippedQuestions++
@@ -787,7 +1273,7 @@ func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionTy
 		}
 
 		if len(answers) == 0 {
-			errMsg := fmt.Sprintf("Question %d: empty answer array", questionNum)
+			errMsg := fmt.Sprintf("%s: empty answer array", importItemLabel(questionNum, q))
 			utils.LogImport("SKIP: %s", errMsg)
 			result.Errors = append(result.Errors, errMsg)
 			result.SkippedQuestions++
@@ -796,7 +1282,7 @@ func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionTy
 
 		answerJSON, err := json.Marshal(answers)
 		if err != nil {
-			errMsg := fmt.Sprintf("Question %d: failed to marshal answer array: %v", questionNum, err)
+			errMsg := fmt.Sprintf("%s: failed to marshal answer array: %v", importItemLabel(questionNum, q), err)
 			utils.LogImport("SKIP: %s", errMsg)
 			result.Errors = append(result.Errors, errMsg)
 			result.SkippedQuestions++
@@ -804,19 +1290,11 @@ func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionTy
 		}
 		return string(answerJSON), nil
 
-	case []string:
-		// Direct string array
-		if len(answerValue) == 0 {
-			errMsg := fmt.Sprintf("Question %d: empty answer array", questionNum)
-			utils.LogImport("SKIP: %s", errMsg)
-			result.Errors = append(result.Errors, errMsg)
-			result.SkippedQuestions++
-			return "", fmt.Errorf("empty answer array")
-		}
-
+	case map[string]interface{}:
+		// Matching pairs, or a numeric answer with its tolerance
 		answerJSON, err := json.Marshal(answerValue)
 		if err != nil {
-			errMsg := fmt.Sprintf("Question %d: failed to marshal answer array: %v", questionNum, err)
+			errMsg := fmt.Sprintf("%s: failed to marshal answer object: %v", importItemLabel(questionNum, q), err)
 			utils.LogImport("SKIP: %s", errMsg)
 			result.Errors = append(result.Errors, errMsg)
 			result.SkippedQuestions++
@@ -825,7 +1303,7 @@ func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionTy
 		return string(answerJSON), nil
 
 	default:
-		errMsg := fmt.Sprintf("Question %d: invalid answer type, must be string or array", questionNum)
+		errMsg := fmt.Sprintf("%s: invalid answer type, must be string, number, array or object", importItemLabel(questionNum, q))
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
@@ -833,16 +1311,31 @@ func (db *DB) processAnswer(questionNum int, q models.QuestionImport, questionTy
 	}
 }
 
+// validateAnswer applies the question type rules, normalizing the answer and the choices
+func (db *DB) validateAnswer(questionNum int, q *models.QuestionImport, questionType, answer string, result *models.ImportResult) (string, error) {
+	finalAnswer, choices, err := utils.ValidateQuestionAnswer(questionType, q.Question, q.Choices, answer)
+	if err != nil {
+		errMsg := fmt.Sprintf("%s: %v", importItemLabel(questionNum, *q), err)
+		utils.LogImport("SKIP: %s", errMsg)
+		result.Errors = append(result.Errors, errMsg)
+		result.SkippedQuestions++
+		return "", err
+	}
+
+	q.Choices = choices
+	return finalAnswer, nil
+}
+
 func (db *DB) validateDifficulty(questionNum int, q models.QuestionImport, result *models.ImportResult) (string, error) {
 	difficulty := strings.ToLower(strings.TrimSpace(q.Difficulty))
 	if difficulty == "" {
 		difficulty = "medium"
-		utils.LogImport("Question %d: using default difficulty 'medium'", questionNum)
+		utils.LogImport("%s: using default difficulty 'medium'", importItemLabel(questionNum, q))
 		return difficulty, nil
 	}
 
 	if difficulty != "easy" && difficulty != "medium" && difficulty != "hard" {
-		errMsg := fmt.Sprintf("Question %d: invalid difficulty '%s', must be easy/medium/hard", questionNum, q.Difficulty)
+		errMsg := fmt.Sprintf("%s: invalid difficulty '%s', must be easy/medium/hard", importItemLabel(questionNum, q), q.Difficulty)
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
@@ -855,7 +1348,7 @@ func (db *DB) validateDifficulty(questionNum int, q models.QuestionImport, resul
 func (db *DB) marshalJSONFields(questionNum int, q models.QuestionImport, result *models.ImportResult) ([]byte, []byte, error) {
 	keywordsJSON, err := json.Marshal(q.Keywords)
 	if err != nil {
-		errMsg := fmt.Sprintf("Question %d: failed to marshal keywords: %v", questionNum, err)
+		errMsg := fmt.Sprintf("%s: failed to marshal keywords: %v", importItemLabel(questionNum, q), err)
 		utils.LogImport("SKIP: %s", errMsg)
 		result.Errors = append(result.Errors, errMsg)
 		result.SkippedQuestions++
@@ -866,7 +1359,7 @@ func (db *DB) marshalJSONFields(questionNum int, q models.QuestionImport, result
 	if len(q.Choices) > 0 {
 		choicesJSON, err = json.Marshal(q.Choices)
 		if err != nil {
-			errMsg := fmt.Sprintf("Question %d: failed to marshal choices: %v", questionNum, err)
+			errMsg := fmt.Sprintf("%s: failed to marshal choices: %v", importItemLabel(questionNum, q), err)
 			utils.LogImport("SKIP: %s", errMsg)
 			result.Errors = append(result.Errors, errMsg)
 			result.SkippedQuestions++
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// SetQuestionApproval approves or rejects a pending question, storing the decision and its reason
+// alongside a revision of the question
+func (db *DB) SetQuestionApproval(questionID, reviewerID int, approve bool, reason string) (*models.Question, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := applyQuestionDecision(tx, questionID, reviewerID, approve, reason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit approval of question %d: %v", questionID, err)
+		return nil, err
+	}
+
+	return db.GetQuestionByID(questionID)
+}
+
+// applyQuestionDecision moves a pending question to approved or rejected inside tx and returns the new status.
+// A question claimed by another moderator is refused so two people never review it at once.
+func applyQuestionDecision(tx *sql.Tx, questionID, reviewerID int, approve bool, reason string) (string, error) {
+	status, decision := "rejected", "reject"
+	if approve {
+		status, decision = "approved", "approve"
+	}
+	utils.LogDB("Setting question %d to %s by user %d", questionID, status, reviewerID)
+
+	var currentStatus string
+	var assignedTo sql.NullInt64
+	var assignedAt *time.Time
+	err := tx.QueryRow("SELECT status, assigned_to, assigned_at FROM questions WHERE id = ?", questionID).
+		Scan(&currentStatus, &assignedTo, &assignedAt)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("question not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to load question %d for review: %v", questionID, err)
+		return "", err
+	}
+
+	if currentStatus != "pending" {
+		return "", fmt.Errorf("question is not pending approval")
+	}
+
+	if claimHeldByOther(assignedTo, assignedAt, reviewerID, time.Now().UTC()) {
+		return "", fmt.Errorf("question is claimed by another moderator")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE questions
+		SET status = ?, approved_by = ?, approved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP,
+		    assigned_to = NULL, assigned_at = NULL
+		WHERE id = ?
+	`, status, reviewerID, questionID)
+	if err != nil {
+		utils.LogError("Failed to update question approval status: %v", err)
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO question_reviews (question_id, reviewer_id, decision, reason, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, questionID, reviewerID, decision, reason, time.Now().UTC())
+	if err != nil {
+		utils.LogError("Failed to record review of question %d: %v", questionID, err)
+		return "", err
+	}
+
+	if err := recordQuestionRevision(tx, questionID, &reviewerID, decision); err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// GetQuestionReviews returns the moderation decisions taken on a question, newest first
+func (db *DB) GetQuestionReviews(questionID int) ([]models.QuestionReview, error) {
+	utils.LogDB("Executing query: GetQuestionReviews(%d)", questionID)
+
+	rows, err := db.Query(`
+		SELECT r.id, r.question_id, r.reviewer_id, COALESCE(u.username, ''), r.decision, r.reason, r.created_at
+		FROM question_reviews r
+		LEFT JOIN users u ON r.reviewer_id = u.id
+		WHERE r.question_id = ?
+		ORDER BY r.created_at DESC, r.id DESC
+	`, questionID)
+	if err != nil {
+		utils.LogError("GetQuestionReviews(%d) failed: %v", questionID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.QuestionReview{}
+	for rows.Next() {
+		var review models.QuestionReview
+		if err := rows.Scan(&review.ID, &review.QuestionID, &review.ReviewerID, &review.ReviewerUsername,
+			&review.Decision, &review.Reason, &review.CreatedAt); err != nil {
+			utils.LogError("Failed to scan question review: %v", err)
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// GetQuestionComments returns the comment thread of a question, oldest first
+func (db *DB) GetQuestionComments(questionID int) ([]models.QuestionComment, error) {
+	utils.LogDB("Executing query: GetQuestionComments(%d)", questionID)
+
+	rows, err := db.Query(`
+		SELECT c.id, c.question_id, c.user_id, COALESCE(u.username, ''), COALESCE(u.role, ''), c.body, c.created_at
+		FROM question_comments c
+		LEFT JOIN users u ON c.user_id = u.id
+		WHERE c.question_id = ?
+		ORDER BY c.created_at ASC, c.id ASC
+	`, questionID)
+	if err != nil {
+		utils.LogError("GetQuestionComments(%d) failed: %v", questionID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.QuestionComment{}
+	for rows.Next() {
+		var comment models.QuestionComment
+		if err := rows.Scan(&comment.ID, &comment.QuestionID, &comment.UserID, &comment.Username, &comment.UserRole,
+			&comment.Body, &comment.CreatedAt); err != nil {
+			utils.LogError("Failed to scan question comment: %v", err)
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// AddQuestionComment appends a comment to the thread of a question
+func (db *DB) AddQuestionComment(questionID, userID int, body string) (*models.QuestionComment, error) {
+	utils.LogDB("Adding comment on question %d by user %d", questionID, userID)
+
+	result, err := db.Exec(`
+		INSERT INTO question_comments (question_id, user_id, body, created_at)
+		VALUES (?, ?, ?, ?)
+	`, questionID, userID, body, time.Now().UTC())
+	if err != nil {
+		utils.LogError("Failed to add comment on question %d: %v", questionID, err)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var comment models.QuestionComment
+	err = db.QueryRow(`
+		SELECT c.id, c.question_id, c.user_id, COALESCE(u.username, ''), COALESCE(u.role, ''), c.body, c.created_at
+		FROM question_comments c
+		LEFT JOIN users u ON c.user_id = u.id
+		WHERE c.id = ?
+	`, id).Scan(&comment.ID, &comment.QuestionID, &comment.UserID, &comment.Username, &comment.UserRole,
+		&comment.Body, &comment.CreatedAt)
+	if err != nil {
+		utils.LogError("Failed to read back comment %d: %v", id, err)
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// ResubmitQuestion moves a rejected question back to pending, posting the optional comment in the same transaction
+func (db *DB) ResubmitQuestion(questionID, userID int, comment string) (*models.Question, error) {
+	utils.LogDB("Resubmitting question %d by user %d", questionID, userID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE questions
+		SET status = 'pending', approved_by = NULL, approved_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'rejected'
+	`, questionID)
+	if err != nil {
+		utils.LogError("Failed to resubmit question %d: %v", questionID, err)
+		return nil, err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("only rejected questions can be resubmitted")
+	}
+
+	if comment != "" {
+		_, err = tx.Exec(`
+			INSERT INTO question_comments (question_id, user_id, body, created_at)
+			VALUES (?, ?, ?, ?)
+		`, questionID, userID, comment, time.Now().UTC())
+		if err != nil {
+			utils.LogError("Failed to add resubmission comment on question %d: %v", questionID, err)
+			return nil, err
+		}
+	}
+
+	if err := recordQuestionRevision(tx, questionID, &userID, "resubmit"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit resubmission of question %d: %v", questionID, err)
+		return nil, err
+	}
+
+	return db.GetQuestionByID(questionID)
+}
@@ -0,0 +1,261 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx so writes can join the caller's transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// revisionTagsSnapshot is the JSON array of the tag slugs of question q, as stored on its revisions
+const revisionTagsSnapshot = `(SELECT json_group_array(slug) FROM (
+		SELECT t.slug FROM question_tags qt JOIN tags t ON t.id = qt.tag_id
+		WHERE qt.question_id = q.id ORDER BY t.slug))`
+
+// recordQuestionRevision snapshots the current state of a question as its next revision
+func recordQuestionRevision(ex execer, questionID int, changedBy *int, changeType string) error {
+	_, err := ex.Exec(`
+		INSERT INTO question_revisions (question_id, revision_number, category, question, question_type, choices,
+		                                answer, keywords, explanation, sources, tags, difficulty, status,
+		                                change_type, changed_by, created_at)
+		SELECT q.id,
+		       COALESCE((SELECT MAX(revision_number) FROM question_revisions WHERE question_id = q.id), 0) + 1,
+		       q.category, q.question, q.question_type, q.choices, q.answer, q.keywords,
+		       q.explanation, q.sources, `+revisionTagsSnapshot+`, q.difficulty, q.status,
+		       ?, ?, ?
+		FROM questions q WHERE q.id = ?
+	`, changeType, changedBy, time.Now().UTC(), questionID)
+	if err != nil {
+		utils.LogError("Failed to record %s revision for question %d: %v", changeType, questionID, err)
+		return err
+	}
+	return nil
+}
+
+// recordImportRevisions gives a first revision to every question that has none, i.e. the rows just imported
+func recordImportRevisions(ex execer, importedBy int) error {
+	_, err := ex.Exec(`
+		INSERT INTO question_revisions (question_id, revision_number, category, question, question_type, choices,
+		                                answer, keywords, explanation, sources, tags, difficulty, status,
+		                                change_type, changed_by, created_at)
+		SELECT q.id, 1, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords,
+		       q.explanation, q.sources, `+revisionTagsSnapshot+`, q.difficulty,
+		       q.status, 'import', ?, ?
+		FROM questions q
+		WHERE NOT EXISTS (SELECT 1 FROM question_revisions r WHERE r.question_id = q.id)
+	`, importedBy, time.Now().UTC())
+	if err != nil {
+		utils.LogError("Failed to record import revisions: %v", err)
+		return err
+	}
+	return nil
+}
+
+const questionRevisionColumns = `r.id, r.question_id, r.revision_number, r.category, r.question, r.question_type, r.choices,
+	r.answer, r.keywords, r.explanation, r.sources, r.tags, r.difficulty, r.status, r.change_type, r.changed_by, u.username, r.created_at`
+
+func scanQuestionRevision(scanner interface{ Scan(...interface{}) error }) (*models.QuestionRevision, error) {
+	var rev models.QuestionRevision
+	var choicesJSON, keywordsJSON, sourcesJSON, tagsJSON, changedByUsername sql.NullString
+	var changedBy sql.NullInt64
+
+	err := scanner.Scan(&rev.ID, &rev.QuestionID, &rev.RevisionNumber, &rev.Category, &rev.Question, &rev.QuestionType,
+		&choicesJSON, &rev.Answer, &keywordsJSON, &rev.Explanation, &sourcesJSON, &tagsJSON, &rev.Difficulty, &rev.Status, &rev.ChangeType, &changedBy,
+		&changedByUsername, &rev.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if choicesJSON.Valid && choicesJSON.String != "" {
+		json.Unmarshal([]byte(choicesJSON.String), &rev.Choices)
+	}
+
+	if keywordsJSON.Valid && keywordsJSON.String != "" {
+		json.Unmarshal([]byte(keywordsJSON.String), &rev.Keywords)
+	}
+
+	if sourcesJSON.Valid && sourcesJSON.String != "" {
+		json.Unmarshal([]byte(sourcesJSON.String), &rev.Sources)
+	}
+
+	if tagsJSON.Valid && tagsJSON.String != "" {
+		json.Unmarshal([]byte(tagsJSON.String), &rev.Tags)
+	}
+
+	if changedBy.Valid {
+		id := int(changedBy.Int64)
+		rev.ChangedBy = &id
+	}
+	rev.ChangedByUsername = changedByUsername.String
+
+	return &rev, nil
+}
+
+// GetQuestionRevisions returns the history of a question, newest first
+func (db *DB) GetQuestionRevisions(questionID int) ([]models.QuestionRevision, error) {
+	utils.LogDB("Executing query: GetQuestionRevisions(%d)", questionID)
+
+	rows, err := db.Query(`
+		SELECT `+questionRevisionColumns+`
+		FROM question_revisions r
+		LEFT JOIN users u ON r.changed_by = u.id
+		WHERE r.question_id = ?
+		ORDER BY r.revision_number DESC
+	`, questionID)
+	if err != nil {
+		utils.LogError("GetQuestionRevisions(%d) failed: %v", questionID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.QuestionRevision{}
+	for rows.Next() {
+		rev, err := scanQuestionRevision(rows)
+		if err != nil {
+			utils.LogError("Failed to scan question revision: %v", err)
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetQuestionRevision returns one revision of a question, or the latest one when revisionNumber is 0
+func (db *DB) GetQuestionRevision(questionID, revisionNumber int) (*models.QuestionRevision, error) {
+	utils.LogDB("Executing query: GetQuestionRevision(%d, %d)", questionID, revisionNumber)
+
+	var row *sql.Row
+	if revisionNumber == 0 {
+		row = db.QueryRow(`
+			SELECT `+questionRevisionColumns+`
+			FROM question_revisions r
+			LEFT JOIN users u ON r.changed_by = u.id
+			WHERE r.question_id = ?
+			ORDER BY r.revision_number DESC LIMIT 1
+		`, questionID)
+	} else {
+		row = db.QueryRow(`
+			SELECT `+questionRevisionColumns+`
+			FROM question_revisions r
+			LEFT JOIN users u ON r.changed_by = u.id
+			WHERE r.question_id = ? AND r.revision_number = ?
+		`, questionID, revisionNumber)
+	}
+
+	rev, err := scanQuestionRevision(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("revision not found")
+		}
+		utils.LogError("GetQuestionRevision(%d, %d) failed: %v", questionID, revisionNumber, err)
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// DiffQuestionRevisions compares two revisions of a question, toRevision 0 meaning the latest
+func (db *DB) DiffQuestionRevisions(questionID, fromRevision, toRevision int) (*models.QuestionRevisionDiff, error) {
+	from, err := db.GetQuestionRevision(questionID, fromRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := db.GetQuestionRevision(questionID, toRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := from.Diff(to)
+	return &diff, nil
+}
+
+// RollbackQuestionToRevision restores the content of an earlier revision. The restored question goes back
+// to pending so it is approved again like any other edit. A category deleted since the revision is not
+// brought back, the question keeps its current one.
+func (db *DB) RollbackQuestionToRevision(questionID, revisionNumber, userID int) (*models.Question, error) {
+	utils.LogDB("Rolling back question %d to revision %d by user %d", questionID, revisionNumber, userID)
+	start := time.Now()
+
+	current, err := db.GetQuestionByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := db.GetQuestionRevision(questionID, revisionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// The revision may predate the deletion of its category, the question then stays where it is now
+	category, err := db.resolveQuestionCategory(rev.Category)
+	if err != nil && strings.Contains(err.Error(), "unknown category") {
+		utils.LogDB("Category '%s' of revision %d no longer exists, question %d stays in '%s'",
+			rev.Category, revisionNumber, questionID, current.Category)
+		category, err = current.Category, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var choicesJSON []byte
+	if len(rev.Choices) > 0 {
+		choicesJSON, _ = json.Marshal(rev.Choices)
+	}
+	keywordsJSON, _ := json.Marshal(rev.Keywords)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE questions
+		SET category = ?, question = ?, question_type = ?, choices = ?, answer = ?, keywords = ?,
+		    explanation = ?, sources = ?, difficulty = ?,
+		    status = 'pending', approved_by = NULL, approved_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, category, rev.Question, rev.QuestionType, string(choicesJSON), rev.Answer, string(keywordsJSON),
+		rev.Explanation, sourcesColumn(rev.Sources), rev.Difficulty, questionID)
+	if err != nil {
+		utils.LogError("Failed to roll back question %d: %v", questionID, err)
+		return nil, err
+	}
+
+	// Tags deleted since the revision was taken are not brought back
+	if err := setQuestionTags(tx, questionID, rev.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := recordQuestionRevision(tx, questionID, &userID, "rollback"); err != nil {
+		return nil, err
+	}
+
+	// Same rule as a regular edit, answers given against another answer key are no longer meaningful
+	if current.Answer != rev.Answer {
+		utils.LogDB("Answer changed for question %d, clearing progress", questionID)
+		if _, err := tx.Exec("DELETE FROM progress WHERE question_id = ?", questionID); err != nil {
+			utils.LogError("Failed to clear progress for question %d: %v", questionID, err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit rollback of question %d: %v", questionID, err)
+		return nil, err
+	}
+
+	utils.LogDB("Question %d rolled back to revision %d in %v", questionID, revisionNumber, time.Since(start))
+	return db.GetQuestionByID(questionID)
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// GetQuestionSchedule returns the spaced repetition state of a question, or nil if it was never reviewed
+func (db *DB) GetQuestionSchedule(userID, questionID int) (*models.QuestionSchedule, error) {
+	var s models.QuestionSchedule
+
+	err := db.QueryRow(`
+		SELECT user_id, question_id, repetitions, interval_days, ease_factor, lapses, due_at, last_reviewed_at
+		FROM question_schedules WHERE user_id = ? AND question_id = ?
+	`, userID, questionID).Scan(&s.UserID, &s.QuestionID, &s.Repetitions, &s.IntervalDays, &s.EaseFactor,
+		&s.Lapses, &s.DueAt, &s.LastReviewedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		utils.LogError("GetQuestionSchedule(%d, %d) failed: %v", userID, questionID, err)
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// UpdateQuestionSchedule reschedules a question after the user answered it
+func (db *DB) UpdateQuestionSchedule(userID, questionID int, isCorrect bool) (*models.QuestionSchedule, error) {
+	current, err := db.GetQuestionSchedule(userID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	next := utils.ScheduleReview(current, utils.ReviewQuality(isCorrect), time.Now().UTC())
+	next.UserID = userID
+	next.QuestionID = questionID
+
+	_, err = db.Exec(`
+		INSERT INTO question_schedules (user_id, question_id, repetitions, interval_days, ease_factor, lapses, due_at, last_reviewed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, question_id) DO UPDATE SET
+			repetitions = excluded.repetitions,
+			interval_days = excluded.interval_days,
+			ease_factor = excluded.ease_factor,
+			lapses = excluded.lapses,
+			due_at = excluded.due_at,
+			last_reviewed_at = excluded.last_reviewed_at
+	`, userID, questionID, next.Repetitions, next.IntervalDays, next.EaseFactor, next.Lapses, next.DueAt, next.LastReviewedAt)
+	if err != nil {
+		utils.LogError("Failed to update schedule for user %d, question %d: %v", userID, questionID, err)
+		return nil, err
+	}
+
+	utils.LogDB("Question %d for user %d due in %d day(s) (ease %.2f, lapses %d)",
+		questionID, userID, next.IntervalDays, next.EaseFactor, next.Lapses)
+	return next, nil
+}
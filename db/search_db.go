@@ -0,0 +1,235 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// setupQuestionSearch creates the FTS5 index over questions and the triggers keeping it in sync.
+// remove_diacritics makes "elysee" match "Élysée".
+func setupQuestionSearch(db *sql.DB) error {
+	var existing int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE name IN ('questions_fts', 'questions_fts_insert', 'questions_fts_delete', 'questions_fts_update')
+	`).Scan(&existing)
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS questions_fts USING fts5(
+			question, answer, keywords, choices,
+			content='questions', content_rowid='id',
+			tokenize='unicode61 remove_diacritics 2'
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS questions_fts_insert AFTER INSERT ON questions BEGIN
+			INSERT INTO questions_fts (rowid, question, answer, keywords, choices)
+			VALUES (new.id, new.question, new.answer, new.keywords, new.choices);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS questions_fts_delete AFTER DELETE ON questions BEGIN
+			INSERT INTO questions_fts (questions_fts, rowid, question, answer, keywords, choices)
+			VALUES ('delete', old.id, old.question, old.answer, old.keywords, old.choices);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS questions_fts_update AFTER UPDATE OF question, answer, keywords, choices ON questions BEGIN
+			INSERT INTO questions_fts (questions_fts, rowid, question, answer, keywords, choices)
+			VALUES ('delete', old.id, old.question, old.answer, old.keywords, old.choices);
+			INSERT INTO questions_fts (rowid, question, answer, keywords, choices)
+			VALUES (new.id, new.question, new.answer, new.keywords, new.choices);
+		END`,
+		// CREATE ... IF NOT EXISTS succeeds on an existing table even when the module is missing, a read does not
+		`SELECT COUNT(*) FROM questions_fts WHERE rowid = 0`,
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			// Triggers left by an FTS5 build would make every write to questions fail without the module
+			for _, trigger := range []string{"questions_fts_insert", "questions_fts_delete", "questions_fts_update"} {
+				db.Exec("DROP TRIGGER IF EXISTS " + trigger)
+			}
+			return err
+		}
+	}
+
+	// Index the questions written while the index was missing or not kept in sync
+	if existing < 4 {
+		utils.LogStartup("Building full-text index over existing questions")
+		if _, err := db.Exec("INSERT INTO questions_fts (questions_fts) VALUES ('rebuild')"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SearchEnabled reports whether full-text search is available in this build
+func (db *DB) SearchEnabled() bool {
+	return db.searchEnabled
+}
+
+// buildSearchQuery turns free text into an FTS5 query: every word must match, as a prefix, and the
+// user's input never reaches the FTS5 syntax parser
+func buildSearchQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// SearchQuestions runs a ranked full-text search over the questions visible to the user. Learners only
+// search the question text and choices, matching on answers or keywords would give the answer away.
+func (db *DB) SearchQuestions(userID int, userRole, text string, limit, offset int) ([]models.QuestionSearchHit, int, error) {
+	utils.LogDB("Searching questions for user %d (role: %s): %q", userID, userRole, text)
+	start := time.Now()
+
+	if !db.searchEnabled {
+		return nil, 0, fmt.Errorf("search is not available")
+	}
+
+	match := buildSearchQuery(text)
+	if match == "" {
+		return nil, 0, fmt.Errorf("invalid search: query must contain at least one word")
+	}
+
+	staff := userRole == "admin" || userRole == "moderator"
+	if !staff {
+		match = "{question choices} : (" + match + ")"
+	}
+
+	where := "questions_fts MATCH ?"
+	args := []interface{}{match}
+	if condition, conditionArgs := questionVisibilityCondition(userID, userRole); condition != "" {
+		where += " AND " + condition
+		args = append(args, conditionArgs...)
+	}
+
+	var total int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM questions_fts
+		JOIN questions q ON q.id = questions_fts.rowid
+		WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		utils.LogError("Failed to count search results: %v", err)
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT q.id, q.category, q.question, q.question_type, q.choices, q.answer, q.keywords, q.explanation, q.sources, q.difficulty,
+		       q.created_by, q.status, q.approved_by, q.approved_at, q.created_at, q.updated_at,
+		       COALESCE(u.username, ''), bm25(questions_fts),
+		       highlight(questions_fts, 0, char(1), char(2)),
+		       highlight(questions_fts, 1, char(1), char(2)),
+		       highlight(questions_fts, 2, char(1), char(2)),
+		       highlight(questions_fts, 3, char(1), char(2))
+		FROM questions_fts
+		JOIN questions q ON q.id = questions_fts.rowid
+		LEFT JOIN users u ON q.created_by = u.id
+		WHERE `+where+`
+		ORDER BY bm25(questions_fts), q.id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		utils.LogError("SearchQuestions query failed: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []models.QuestionSearchHit{}
+	for rows.Next() {
+		var hit models.QuestionSearchHit
+		var keywordsJSON, sourcesJSON, choicesJSON, answerHighlight, keywordsHighlight, choicesHighlight sql.NullString
+		var questionHighlight string
+		q := &hit.Question
+
+		err := rows.Scan(&q.ID, &q.Category, &q.Question, &q.QuestionType, &choicesJSON, &q.Answer, &keywordsJSON,
+			&q.Explanation, &sourcesJSON, &q.Difficulty, &q.CreatedBy, &q.Status, &q.ApprovedBy, &q.ApprovedAt, &q.CreatedAt, &q.UpdatedAt,
+			&q.CreatorUsername, &hit.Rank, &questionHighlight, &answerHighlight, &keywordsHighlight, &choicesHighlight)
+		if err != nil {
+			utils.LogError("Failed to scan search result: %v", err)
+			return nil, 0, err
+		}
+
+		if keywordsJSON.Valid && keywordsJSON.String != "" {
+			json.Unmarshal([]byte(keywordsJSON.String), &q.Keywords)
+		}
+
+		if sourcesJSON.Valid && sourcesJSON.String != "" {
+			json.Unmarshal([]byte(sourcesJSON.String), &q.Sources)
+		}
+
+		if choicesJSON.Valid && choicesJSON.String != "" {
+			json.Unmarshal([]byte(choicesJSON.String), &q.Choices)
+		}
+
+		// bm25 scores are negative, the best match has the lowest one
+		hit.Rank = -hit.Rank
+		hit.Highlights = map[string]string{"question": renderHighlight(questionHighlight)}
+		// Ordering and matching choices are stored in answer order, learners only get them shuffled
+		answerOrdered := q.QuestionType == "ordering" || q.QuestionType == "matching"
+		if hasHighlight(choicesHighlight) && (staff || !answerOrdered) {
+			hit.Highlights["choices"] = renderHighlight(choicesHighlight.String)
+		}
+		if staff {
+			if hasHighlight(answerHighlight) {
+				hit.Highlights["answer"] = renderHighlight(answerHighlight.String)
+			}
+			if hasHighlight(keywordsHighlight) {
+				hit.Highlights["keywords"] = renderHighlight(keywordsHighlight.String)
+			}
+		}
+
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	tags, err := db.loadQuestionTags(hitIDs(hits))
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range hits {
+		hits[i].Question.Tags = tags[hits[i].Question.ID]
+	}
+
+	utils.LogDB("Search %q returned %d of %d results in %v", text, len(hits), total, time.Since(start))
+	return hits, total, nil
+}
+
+func hitIDs(hits []models.QuestionSearchHit) []int {
+	ids := make([]int, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Question.ID
+	}
+	return ids
+}
+
+// highlight() marks matches with these control characters rather than HTML, since the column text
+// it returns is not escaped
+const (
+	highlightStart = "\x01"
+	highlightEnd   = "\x02"
+)
+
+func hasHighlight(column sql.NullString) bool {
+	return column.Valid && strings.Contains(column.String, highlightStart)
+}
+
+// renderHighlight escapes a highlighted column as HTML, then turns its markers into <mark> tags
+func renderHighlight(text string) string {
+	return strings.NewReplacer(highlightStart, "<mark>", highlightEnd, "</mark>").Replace(html.EscapeString(text))
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// Sessions are keyed by the hash of their token, the token itself is never stored
+
+func (db *DB) CreateSession(session *models.Session) error {
+	utils.LogDB("Creating session for user %d", session.UserID)
+
+	_, err := db.Exec(`
+		INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, utils.HashToken(session.ID), session.UserID, session.UserAgent, session.IPAddress,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+
+	if err != nil {
+		utils.LogError("Failed to create session for user %d: %v", session.UserID, err)
+		return err
+	}
+
+	return nil
+}
+
+func (db *DB) GetSessionByToken(sessionID string) (*models.Session, error) {
+	session := models.Session{ID: sessionID}
+	var lastSeenAt *time.Time
+
+	err := db.QueryRow(`
+		SELECT s.user_id, u.username, u.role, s.user_agent, s.ip_address, s.created_at, s.last_seen_at, s.expires_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = ?
+	`, utils.HashToken(sessionID)).Scan(&session.UserID, &session.Username, &session.Role,
+		&session.UserAgent, &session.IPAddress, &session.CreatedAt, &lastSeenAt, &session.ExpiresAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.LastSeenAt = session.CreatedAt
+	if lastSeenAt != nil {
+		session.LastSeenAt = *lastSeenAt
+	}
+
+	return &session, nil
+}
+
+// TouchSession records activity on a session and slides its expiry
+func (db *DB) TouchSession(sessionID string, lastSeenAt, expiresAt time.Time) error {
+	_, err := db.Exec("UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?",
+		lastSeenAt, expiresAt, utils.HashToken(sessionID))
+	return err
+}
+
+func (db *DB) GetUserSessions(userID int) ([]models.SessionInfo, error) {
+	utils.LogDB("Listing sessions for user %d", userID)
+
+	rows, err := db.Query(`
+		SELECT id, user_agent, ip_address, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY COALESCE(last_seen_at, created_at) DESC
+	`, userID, time.Now().UTC())
+	if err != nil {
+		utils.LogError("GetUserSessions(%d) failed: %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.SessionInfo{}
+	for rows.Next() {
+		var info models.SessionInfo
+		var lastSeenAt *time.Time
+		if err := rows.Scan(&info.ID, &info.UserAgent, &info.IPAddress, &info.CreatedAt, &lastSeenAt, &info.ExpiresAt); err != nil {
+			utils.LogError("Failed to scan session row: %v", err)
+			return nil, err
+		}
+		info.LastSeenAt = info.CreatedAt
+		if lastSeenAt != nil {
+			info.LastSeenAt = *lastSeenAt
+		}
+		sessions = append(sessions, info)
+	}
+
+	return sessions, nil
+}
+
+func (db *DB) DeleteUserSessionByHash(userID int, sessionHash string) (bool, error) {
+	result, err := db.Exec("DELETE FROM sessions WHERE id = ? AND user_id = ?", sessionHash, userID)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted > 0, nil
+}
+
+func (db *DB) DeleteOtherUserSessions(userID int, keepSessionID string) (int64, error) {
+	utils.LogDB("Deleting other sessions for user %d", userID)
+
+	result, err := db.Exec("DELETE FROM sessions WHERE user_id = ? AND id != ?", userID, utils.HashToken(keepSessionID))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
+
+func (db *DB) DeleteSession(sessionID string) error {
+	_, err := db.Exec("DELETE FROM sessions WHERE id = ?", utils.HashToken(sessionID))
+	return err
+}
+
+func (db *DB) DeleteUserSessions(userID int) (int64, error) {
+	utils.LogDB("Deleting all sessions for user %d", userID)
+
+	result, err := db.Exec("DELETE FROM sessions WHERE user_id = ?", userID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, _ := result.RowsAffected()
+	utils.LogDB("Deleted %d sessions for user %d", deleted, userID)
+	return deleted, nil
+}
+
+func (db *DB) DeleteExpiredSessions() (int64, error) {
+	result, err := db.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
@@ -0,0 +1,286 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+func (db *DB) loadTagIndex() (*slugIndex, error) {
+	return db.loadSlugIndex("tags")
+}
+
+// ResolveTags maps tag names or slugs to their slugs without duplicates, failing on the first unknown one
+func (db *DB) ResolveTags(tags []string) ([]string, error) {
+	idx, err := db.loadTagIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	slugs, unknown := resolveTagList(idx, tags)
+	if unknown != "" {
+		return nil, fmt.Errorf("invalid tag: %s", unknown)
+	}
+	return slugs, nil
+}
+
+// resolveTagList resolves tags against the index, returning the first unknown one if any
+func resolveTagList(idx *slugIndex, tags []string) ([]string, string) {
+	slugs := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		slug, ok := idx.resolve(tag)
+		if !ok {
+			return nil, strings.TrimSpace(tag)
+		}
+		if !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs, ""
+}
+
+// resolveQuestionTags checks the tags of a created or updated question
+func (db *DB) resolveQuestionTags(tags []string) ([]string, error) {
+	idx, err := db.loadTagIndex()
+	if err != nil {
+		return nil, err
+	}
+	slugs, unknown := resolveTagList(idx, tags)
+	if unknown != "" {
+		return nil, fmt.Errorf("invalid question: unknown tag '%s'", unknown)
+	}
+	return slugs, nil
+}
+
+// setQuestionTags replaces the tags of a question, given as slugs
+func setQuestionTags(tx *sql.Tx, questionID int, slugs []string) error {
+	if _, err := tx.Exec("DELETE FROM question_tags WHERE question_id = ?", questionID); err != nil {
+		utils.LogError("Failed to clear tags of question %d: %v", questionID, err)
+		return err
+	}
+	for _, slug := range slugs {
+		_, err := tx.Exec("INSERT OR IGNORE INTO question_tags (question_id, tag_id) SELECT ?, id FROM tags WHERE slug = ?",
+			questionID, slug)
+		if err != nil {
+			utils.LogError("Failed to tag question %d with '%s': %v", questionID, slug, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// loadQuestionTags returns the tag slugs of the given questions, or of every question when ids is nil
+func (db *DB) loadQuestionTags(ids []int) (map[int][]string, error) {
+	tags := make(map[int][]string)
+	if ids != nil && len(ids) == 0 {
+		return tags, nil
+	}
+
+	query := `SELECT qt.question_id, t.slug FROM question_tags qt JOIN tags t ON t.id = qt.tag_id`
+	var args []interface{}
+	if ids != nil {
+		placeholders := strings.Repeat("?,", len(ids))
+		query += fmt.Sprintf(" WHERE qt.question_id IN (%s)", placeholders[:len(placeholders)-1])
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		utils.LogError("Failed to load question tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questionID int
+		var slug string
+		if err := rows.Scan(&questionID, &slug); err != nil {
+			return nil, err
+		}
+		tags[questionID] = append(tags[questionID], slug)
+	}
+	for _, slugs := range tags {
+		sort.Strings(slugs)
+	}
+
+	return tags, rows.Err()
+}
+
+// attachQuestionTags fills the tags of the questions in place
+func (db *DB) attachQuestionTags(questions []models.Question) error {
+	ids := make([]int, len(questions))
+	for i := range questions {
+		ids[i] = questions[i].ID
+	}
+
+	tags, err := db.loadQuestionTags(ids)
+	if err != nil {
+		return err
+	}
+	for i := range questions {
+		questions[i].Tags = tags[questions[i].ID]
+	}
+	return nil
+}
+
+// questionTagCondition restricts a query on questions q to those carrying the tag
+const questionTagCondition = "q.id IN (SELECT qt.question_id FROM question_tags qt JOIN tags t ON t.id = qt.tag_id WHERE t.slug = ?)"
+
+const tagColumns = `t.id, t.slug, t.name, t.description, t.created_at, t.updated_at,
+	(SELECT COUNT(*) FROM question_tags qt JOIN questions q ON q.id = qt.question_id
+	 WHERE qt.tag_id = t.id AND q.status = 'approved')`
+
+func scanTag(scanner interface{ Scan(...interface{}) error }) (*models.Tag, error) {
+	var t models.Tag
+	if err := scanner.Scan(&t.ID, &t.Slug, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt, &t.QuestionCount); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTags returns every tag by name
+func (db *DB) GetTags() ([]models.Tag, error) {
+	utils.LogDB("Executing query: GetTags")
+
+	rows, err := db.Query(`SELECT ` + tagColumns + ` FROM tags t ORDER BY t.name`)
+	if err != nil {
+		utils.LogError("GetTags failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			utils.LogError("Failed to scan tag: %v", err)
+			return nil, err
+		}
+		tags = append(tags, *t)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetTag returns a single tag
+func (db *DB) GetTag(id int) (*models.Tag, error) {
+	t, err := scanTag(db.QueryRow(`SELECT `+tagColumns+` FROM tags t WHERE t.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tag not found")
+	}
+	if err != nil {
+		utils.LogError("Failed to get tag %d: %v", id, err)
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateTag adds a tag
+func (db *DB) CreateTag(req models.TagRequest) (*models.Tag, error) {
+	utils.LogDB("Creating tag '%s' (%s)", req.Name, req.Slug)
+
+	now := time.Now().UTC()
+	result, err := db.Exec(`
+		INSERT INTO tags (slug, name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, req.Slug, req.Name, req.Description, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("tag slug already exists")
+		}
+		utils.LogError("Failed to create tag: %v", err)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogDB("Tag %d '%s' created", id, req.Slug)
+	return db.GetTag(int(id))
+}
+
+// UpdateTag replaces the name and description of a tag
+func (db *DB) UpdateTag(id int, req models.TagRequest) (*models.Tag, error) {
+	utils.LogDB("Updating tag %d", id)
+
+	var slug string
+	err := db.QueryRow("SELECT slug FROM tags WHERE id = ?", id).Scan(&slug)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tag not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.Slug != "" && req.Slug != slug {
+		return nil, fmt.Errorf("tag slug can not be changed")
+	}
+
+	_, err = db.Exec("UPDATE tags SET name = ?, description = ?, updated_at = ? WHERE id = ?",
+		req.Name, req.Description, time.Now().UTC(), id)
+	if err != nil {
+		utils.LogError("Failed to update tag %d: %v", id, err)
+		return nil, err
+	}
+
+	return db.GetTag(id)
+}
+
+// DeleteTag removes a tag from every question and learner preference carrying it, then deletes it
+func (db *DB) DeleteTag(id int) error {
+	utils.LogDB("Deleting tag %d", id)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var slug string
+	err = tx.QueryRow("SELECT slug FROM tags WHERE id = ?", id).Scan(&slug)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("tag not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	untagged, err := tx.Exec("DELETE FROM question_tags WHERE tag_id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to untag questions of tag %d: %v", id, err)
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM tags WHERE id = ?", id)
+	if err != nil {
+		utils.LogError("Failed to delete tag %d: %v", id, err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tag not found")
+	}
+
+	if err := replacePreferenceSlug(tx, "tag_preference", slug, ""); err != nil {
+		utils.LogError("Failed to update tag preferences for tag %d: %v", id, err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.LogError("Failed to commit tag deletion: %v", err)
+		return err
+	}
+
+	count, _ := untagged.RowsAffected()
+	utils.LogDB("Tag %d '%s' deleted, removed from %d questions", id, slug, count)
+	return nil
+}
@@ -9,13 +9,15 @@ require (
 	golang.org/x/crypto v0.39.0
 )
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/hibiken/asynq v0.25.1
+	github.com/joho/godotenv v1.5.1
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/hibiken/asynq v0.25.1 // indirect
 	github.com/redis/go-redis/v9 v9.7.0 // indirect
 	github.com/robfig/cron/v3 v3.0.1 // indirect
 	github.com/spf13/cast v1.7.0 // indirect
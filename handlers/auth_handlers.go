@@ -15,13 +15,13 @@ import (
 
 type AuthHandlers struct {
 	db           *db.DB
-	sessionStore *auth.SessionStore
+	sessionStore auth.SessionStore
 	emailService *auth.EmailService
 	emailConfig  *models.EmailConfig
 	jobManager   *jobs.JobManager // Assuming you have a job manager for email jobs
 }
 
-func NewAuthHandlers(database *db.DB, sessionStore *auth.SessionStore, emailService *auth.EmailService, emailConfig *models.EmailConfig, jobManager *jobs.JobManager) *AuthHandlers {
+func NewAuthHandlers(database *db.DB, sessionStore auth.SessionStore, emailService *auth.EmailService, emailConfig *models.EmailConfig, jobManager *jobs.JobManager) *AuthHandlers {
 	return &AuthHandlers{
 		db:           database,
 		sessionStore: sessionStore,
@@ -41,10 +41,20 @@ func (ah *AuthHandlers) HandleAuth(w http.ResponseWriter, r *http.Request) {
 		ah.login(w, r)
 	case path == "logout" && r.Method == http.MethodPost:
 		ah.logout(w, r)
+	case path == "logout-others" && r.Method == http.MethodPost:
+		ah.logoutOthers(w, r)
+	case path == "sessions" && r.Method == http.MethodGet:
+		ah.listSessions(w, r)
+	case strings.HasPrefix(path, "sessions/") && r.Method == http.MethodDelete:
+		ah.revokeSession(w, r)
 	case path == "me" && r.Method == http.MethodGet:
 		ah.getCurrentUserInfo(w, r)
 	case path == "verify-email" && r.Method == http.MethodGet:
 		ah.verifyEmail(w, r)
+	case path == "forgot-password" && r.Method == http.MethodPost:
+		ah.forgotPassword(w, r)
+	case path == "reset-password" && r.Method == http.MethodPost:
+		ah.resetPassword(w, r)
 	case path == "resend-verification" && r.Method == http.MethodPost:
 		ah.resendVerification(w, r)
 	case strings.HasPrefix(path, "resend-verification/") && r.Method == http.MethodPost:
@@ -101,7 +111,12 @@ func (ah *AuthHandlers) register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create session for immediate login
-	session := ah.sessionStore.CreateSession(user)
+	session, err := ah.sessionStore.CreateSession(user, r.UserAgent(), clientIP(r))
+	if err != nil {
+		utils.LogError("Failed to create session for new user %d: %v", user.ID, err)
+		http.Error(w, "Registration succeeded but login failed, please log in", http.StatusInternalServerError)
+		return
+	}
 
 	utils.LogHTTP("User registered successfully: %s (ID: %d)", user.Username, user.ID)
 
@@ -133,7 +148,12 @@ func (ah *AuthHandlers) login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create session
-	session := ah.sessionStore.CreateSession(user)
+	session, err := ah.sessionStore.CreateSession(user, r.UserAgent(), clientIP(r))
+	if err != nil {
+		utils.LogError("Failed to create session for user %d: %v", user.ID, err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
 
 	utils.LogHTTP("User logged in successfully: %s (ID: %d)", user.Username, user.ID)
 
@@ -160,6 +180,176 @@ func (ah *AuthHandlers) logout(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (ah *AuthHandlers) listSessions(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("GET /auth/sessions")
+
+	session := getSessionFromRequest(r, ah.sessionStore)
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := ah.sessionStore.ListUserSessions(session.UserID)
+	if err != nil {
+		utils.LogError("Failed to list sessions for user %d: %v", session.UserID, err)
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	currentHash := utils.HashToken(session.ID)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentHash
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+func (ah *AuthHandlers) revokeSession(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("DELETE %s", r.URL.Path)
+
+	session := getSessionFromRequest(r, ah.sessionStore)
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	sessionHash := strings.TrimPrefix(r.URL.Path, "/auth/sessions/")
+	if sessionHash == "" {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	// Users can only revoke their own sessions
+	deleted, err := ah.sessionStore.DeleteUserSession(session.UserID, sessionHash)
+	if err != nil {
+		utils.LogError("Failed to revoke session for user %d: %v", session.UserID, err)
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	utils.LogHTTP("User %s revoked session %s", session.Username, sessionHash[:min(8, len(sessionHash))]+"...")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *AuthHandlers) logoutOthers(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("POST /auth/logout-others")
+
+	session := getSessionFromRequest(r, ah.sessionStore)
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	deleted, err := ah.sessionStore.DeleteOtherUserSessions(session.UserID, session.ID)
+	if err != nil {
+		utils.LogError("Failed to log out other sessions for user %d: %v", session.UserID, err)
+		http.Error(w, "Failed to log out other sessions", http.StatusInternalServerError)
+		return
+	}
+
+	utils.LogHTTP("User %s logged out %d other sessions", session.Username, deleted)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          "Logged out of all other sessions",
+		"revoked_sessions": deleted,
+	})
+}
+
+func (ah *AuthHandlers) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("POST /auth/forgot-password")
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in forgot password request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	// Always answer the same way so the endpoint cannot be used to discover accounts
+	response := map[string]interface{}{
+		"message": "If an account exists for this email, a password reset link has been sent.",
+	}
+
+	user, err := ah.db.GetUserByEmail(email)
+	if err != nil || !user.IsActive {
+		utils.LogHTTP("Password reset requested for unknown or inactive email")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	// Failures are only logged, an error answered for existing accounts alone would reveal them
+	reset, err := ah.db.CreatePasswordReset(user.ID, ah.emailConfig.PasswordResetTTL)
+	if err != nil {
+		utils.LogError("Failed to create password reset for user %d: %v", user.ID, err)
+	} else {
+		// Build email content
+		subject, body := ah.emailService.BuildPasswordResetEmail(user, reset.Token)
+
+		// Queue password reset email
+		if err := ah.jobManager.QueuePasswordResetEmail(user.Email, subject, body, user.ID, reset.Token); err != nil {
+			utils.LogError("Failed to queue password reset email for user %d: %v", user.ID, err)
+		} else {
+			utils.LogHTTP("Password reset email queued for user: %s (ID: %d)", user.Username, user.ID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (ah *AuthHandlers) resetPassword(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("POST /auth/reset-password")
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in reset password request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" || req.NewPassword == "" {
+		http.Error(w, "Token and new password are required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := ah.db.ResetPasswordWithToken(req.Token, req.NewPassword)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid or expired") || strings.Contains(err.Error(), "password must be") {
+			utils.LogHTTP("Password reset failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			utils.LogError("Failed to reset password: %v", err)
+			http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Kill all sessions for this user (force re-login everywhere)
+	ah.sessionStore.DeleteUserSessions(user.ID)
+
+	utils.LogHTTP("Password reset for user %s (ID: %d)", user.Username, user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Password reset successfully. Please log in with your new password.",
+	})
+}
+
 func (ah *AuthHandlers) forcePasswordChange(w http.ResponseWriter, r *http.Request) {
 	session := getSessionFromRequest(r, ah.sessionStore)
 	if session == nil {
@@ -295,6 +485,19 @@ func (ah *AuthHandlers) verifyEmail(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "static/verify-email.html")
 }
 
+// resetPasswordPage serves the form password reset emails link to, the token stays in the query string
+func (ah *AuthHandlers) resetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /reset-password", r.Method)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The token must not leak to other sites through the Referer header
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.ServeFile(w, r, "static/reset-password.html")
+}
+
 func (ah *AuthHandlers) resendVerification(w http.ResponseWriter, r *http.Request) {
 	utils.LogHTTP("POST /auth/resend-verification")
 
@@ -708,7 +911,7 @@ func (ah *AuthHandlers) deleteSelf(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getSessionFromRequest(r *http.Request, sessionStore *auth.SessionStore) *models.Session {
+func getSessionFromRequest(r *http.Request, sessionStore auth.SessionStore) *models.Session {
 	sessionID := extractSessionFromRequest(r)
 	if sessionID == "" {
 		return nil
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adamspd/QuizzApi/auth"
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+type CategoryHandlers struct {
+	db           *db.DB
+	sessionStore auth.SessionStore
+}
+
+func NewCategoryHandlers(database *db.DB, sessionStore auth.SessionStore) *CategoryHandlers {
+	return &CategoryHandlers{
+		db:           database,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandleCategories handles GET/POST /categories
+func (ch *CategoryHandlers) HandleCategories(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /categories", r.Method)
+	switch r.Method {
+	case http.MethodGet:
+		ch.getCategories(w, r)
+	case http.MethodPost:
+		ch.createCategory(w, r)
+	default:
+		utils.LogHTTP("Method %s not allowed for /categories", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleCategoryByID handles GET/PUT/DELETE /categories/{id}
+func (ch *CategoryHandlers) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/categories/")
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		utils.LogHTTP("Invalid category ID: %s", path)
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("%s /categories/%d", r.Method, id)
+	switch r.Method {
+	case http.MethodGet:
+		ch.getCategory(w, r, id)
+	case http.MethodPut:
+		ch.updateCategory(w, r, id)
+	case http.MethodDelete:
+		ch.deleteCategory(w, r, id)
+	default:
+		utils.LogHTTP("Method %s not allowed for /categories/%d", r.Method, id)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ch *CategoryHandlers) getCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := ch.db.GetCategories()
+	if err != nil {
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"categories": categories,
+		"count":      len(categories),
+	})
+}
+
+func (ch *CategoryHandlers) getCategory(w http.ResponseWriter, r *http.Request, id int) {
+	category, err := ch.db.GetCategory(id)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to fetch category")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (ch *CategoryHandlers) createCategory(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if session.Role != "admin" {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in category request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateCategoryRequest(&req, true); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	category, err := ch.db.CreateCategory(req)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to create category")
+		return
+	}
+
+	utils.LogHTTP("Category %d '%s' created by %s", category.ID, category.Slug, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+func (ch *CategoryHandlers) updateCategory(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if session.Role != "admin" {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in category request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateCategoryRequest(&req, false); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	category, err := ch.db.UpdateCategory(id, req)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to update category")
+		return
+	}
+
+	utils.LogHTTP("Category %d updated by %s", id, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// deleteCategory handles DELETE /categories/{id}. A category still holding questions or drawn from by
+// exam blueprints is only deleted with ?reassign_to={slug}, which moves them to that category first.
+// Learner preferences follow reassign_to, or lose the category without it.
+func (ch *CategoryHandlers) deleteCategory(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if session.Role != "admin" {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	reassignTo := strings.TrimSpace(r.URL.Query().Get("reassign_to"))
+	moved, err := ch.db.DeleteCategory(id, reassignTo, session.UserID)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to delete category")
+		return
+	}
+
+	utils.LogHTTP("Category %d deleted by %s, %d questions moved", id, session.Username, moved)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateCategoryRequest(req *models.CategoryRequest, creating bool) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Slug = strings.TrimSpace(req.Slug)
+
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(req.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+
+	if creating {
+		if req.Slug == "" {
+			req.Slug = utils.CategorySlug(req.Name)
+		}
+		if !utils.IsValidCategorySlug(req.Slug) {
+			return fmt.Errorf("slug must be at most 64 lowercase letters, digits, hyphens or underscores")
+		}
+	}
+
+	if req.Position != nil && *req.Position < 0 {
+		return fmt.Errorf("position must not be negative")
+	}
+
+	return nil
+}
+
+func writeCategoryError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case err.Error() == "category not found":
+		http.Error(w, "Category not found", http.StatusNotFound)
+	case strings.Contains(err.Error(), "already exists"),
+		strings.Contains(err.Error(), "sub-categories, move"),
+		strings.HasPrefix(err.Error(), "category is used by"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err.Error() == "parent category not found",
+		err.Error() == "reassign_to category not found",
+		err.Error() == "category slug can not be changed",
+		strings.HasPrefix(err.Error(), "a category can not be nested"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/auth"
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+type ExamHandlers struct {
+	db           *db.DB
+	sessionStore auth.SessionStore
+}
+
+func NewExamHandlers(database *db.DB, sessionStore auth.SessionStore) *ExamHandlers {
+	return &ExamHandlers{
+		db:           database,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandleBlueprints handles GET/POST /exams/blueprints
+func (eh *ExamHandlers) HandleBlueprints(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /exams/blueprints", r.Method)
+	switch r.Method {
+	case http.MethodGet:
+		eh.getBlueprints(w, r)
+	case http.MethodPost:
+		eh.createBlueprint(w, r)
+	default:
+		utils.LogHTTP("Method %s not allowed for /exams/blueprints", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleBlueprintByID handles GET/PUT/DELETE /exams/blueprints/{id}
+func (eh *ExamHandlers) HandleBlueprintByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/exams/blueprints/")
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		utils.LogHTTP("Invalid exam blueprint ID: %s", path)
+		http.Error(w, "Invalid exam blueprint ID", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("%s /exams/blueprints/%d", r.Method, id)
+	switch r.Method {
+	case http.MethodGet:
+		eh.getBlueprint(w, r, id)
+	case http.MethodPut:
+		eh.updateBlueprint(w, r, id)
+	case http.MethodDelete:
+		eh.deleteBlueprint(w, r, id)
+	default:
+		utils.LogHTTP("Method %s not allowed for /exams/blueprints/%d", r.Method, id)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleAttempts handles GET/POST /exams/attempts
+func (eh *ExamHandlers) HandleAttempts(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /exams/attempts", r.Method)
+	switch r.Method {
+	case http.MethodGet:
+		eh.getAttempts(w, r)
+	case http.MethodPost:
+		eh.startAttempt(w, r)
+	default:
+		utils.LogHTTP("Method %s not allowed for /exams/attempts", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleAttemptByID handles /exams/attempts/{id}, /answers and /submit
+func (eh *ExamHandlers) HandleAttemptByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/exams/attempts/")
+	parts := strings.Split(path, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		utils.LogHTTP("Invalid exam attempt ID: %s", parts[0])
+		http.Error(w, "Invalid exam attempt ID", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("%s /exams/attempts/%s", r.Method, path)
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		eh.getAttempt(w, r, id)
+	case len(parts) == 2 && parts[1] == "answers" && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		eh.saveAnswers(w, r, id)
+	case len(parts) == 2 && parts[1] == "submit" && r.Method == http.MethodPost:
+		eh.submitAttempt(w, r, id)
+	case len(parts) <= 2:
+		utils.LogHTTP("Method %s not allowed for /exams/attempts/%s", r.Method, path)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (eh *ExamHandlers) getBlueprints(w http.ResponseWriter, r *http.Request) {
+	blueprints, err := eh.db.GetExamBlueprints()
+	if err != nil {
+		utils.LogError("Failed to fetch exam blueprints: %v", err)
+		http.Error(w, "Failed to fetch exam blueprints", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"blueprints": blueprints,
+		"count":      len(blueprints),
+	})
+}
+
+func (eh *ExamHandlers) getBlueprint(w http.ResponseWriter, r *http.Request, id int) {
+	blueprint, err := eh.db.GetExamBlueprint(id)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to fetch exam blueprint")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blueprint)
+}
+
+func (eh *ExamHandlers) createBlueprint(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	if session.Role != "admin" {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	var req models.ExamBlueprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in exam blueprint request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateExamBlueprintRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := eh.resolveBlueprintCategories(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blueprint, err := eh.db.CreateExamBlueprint(req, session.UserID)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to create exam blueprint")
+		return
+	}
+
+	utils.LogHTTP("Exam blueprint %d created by %s", blueprint.ID, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(blueprint)
+}
+
+func (eh *ExamHandlers) updateBlueprint(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	if session.Role != "admin" {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	var req models.ExamBlueprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in exam blueprint request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateExamBlueprintRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := eh.resolveBlueprintCategories(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blueprint, err := eh.db.UpdateExamBlueprint(id, req)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to update exam blueprint")
+		return
+	}
+
+	utils.LogHTTP("Exam blueprint %d updated by %s", id, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blueprint)
+}
+
+func (eh *ExamHandlers) deleteBlueprint(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	if session.Role != "admin" {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := eh.db.DeleteExamBlueprint(id); err != nil {
+		eh.writeExamError(w, err, "Failed to delete exam blueprint")
+		return
+	}
+
+	utils.LogHTTP("Exam blueprint %d deleted by %s", id, session.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (eh *ExamHandlers) getAttempts(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	attempts, err := eh.db.GetUserExamAttempts(session.UserID)
+	if err != nil {
+		utils.LogError("Failed to fetch exam attempts for user %d: %v", session.UserID, err)
+		http.Error(w, "Failed to fetch exam attempts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attempts": attempts,
+		"count":    len(attempts),
+	})
+}
+
+func (eh *ExamHandlers) startAttempt(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional; without a blueprint_id the default blueprint is used
+	var req struct {
+		BlueprintID int `json:"blueprint_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.LogHTTP("Invalid JSON in exam attempt request: %v", err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	attempt, questions, err := eh.db.StartExamAttempt(session.UserID, req.BlueprintID)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to start exam")
+		return
+	}
+
+	utils.LogHTTP("Exam attempt %d started for user %s with %d questions", attempt.ID, session.Username, len(questions))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attempt":   attempt,
+		"questions": examQuestionsView(questions),
+	})
+}
+
+func (eh *ExamHandlers) getAttempt(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	attempt, err := eh.db.GetExamAttempt(id, session.UserID)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to fetch exam attempt")
+		return
+	}
+
+	if attempt.Status != "in_progress" {
+		result, err := eh.db.GetExamResult(id, session.UserID)
+		if err != nil {
+			eh.writeExamError(w, err, "Failed to fetch exam attempt")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	questions, err := eh.db.GetExamAttemptQuestions(attempt)
+	if err != nil {
+		utils.LogError("Failed to load questions of exam attempt %d: %v", id, err)
+		http.Error(w, "Failed to fetch exam attempt", http.StatusInternalServerError)
+		return
+	}
+
+	answers, err := eh.db.GetExamAttemptAnswers(id)
+	if err != nil {
+		http.Error(w, "Failed to fetch exam attempt", http.StatusInternalServerError)
+		return
+	}
+
+	saved := make([]models.ExamAnswer, 0, len(answers))
+	for _, questionID := range attempt.QuestionIDs {
+		if answer, ok := answers[questionID]; ok {
+			saved = append(saved, models.ExamAnswer{QuestionID: questionID, UserAnswer: answer})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attempt":   attempt,
+		"questions": examQuestionsView(questions),
+		"answers":   saved,
+	})
+}
+
+func (eh *ExamHandlers) saveAnswers(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ExamAnswersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in exam answers: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Answers) == 0 {
+		http.Error(w, "No answers provided", http.StatusBadRequest)
+		return
+	}
+
+	for _, answer := range req.Answers {
+		if answer.QuestionID == 0 || answer.UserAnswer == "" {
+			http.Error(w, "Missing required fields", http.StatusBadRequest)
+			return
+		}
+	}
+
+	attempt, err := eh.db.SaveExamAnswers(id, session.UserID, req.Answers)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to save answers")
+		return
+	}
+
+	utils.LogHTTP("Saved %d answers for exam attempt %d (%d/%d answered)", len(req.Answers), id, attempt.Answered, attempt.Total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attempt)
+}
+
+func (eh *ExamHandlers) submitAttempt(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := eh.db.SubmitExamAttempt(id, session.UserID)
+	if err != nil {
+		eh.writeExamError(w, err, "Failed to submit exam")
+		return
+	}
+
+	utils.LogHTTP("Exam attempt %d %s for user %s", id, result.Attempt.Status, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// examQuestionsView never carries answer keys, not even for moderators sitting an exam
+func examQuestionsView(questions []models.Question) []models.LearnerQuestion {
+	learnerQuestions := make([]models.LearnerQuestion, 0, len(questions))
+	for i := range questions {
+		learnerQuestions = append(learnerQuestions, questions[i].LearnerView())
+	}
+	return learnerQuestions
+}
+
+func validateExamBlueprintRequest(req *models.ExamBlueprintRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if req.TimeLimitMinutes < 1 || req.TimeLimitMinutes > 600 {
+		return fmt.Errorf("time_limit_minutes must be between 1 and 600")
+	}
+
+	if req.PassMark <= 0 || req.PassMark > 1 {
+		return fmt.Errorf("pass_mark must be greater than 0 and at most 1")
+	}
+
+	if len(req.Sections) == 0 {
+		return fmt.Errorf("at least one section is required")
+	}
+
+	total := 0
+	validDifficulties := []string{"easy", "medium", "hard"}
+	for i := range req.Sections {
+		section := &req.Sections[i]
+		section.Category = strings.TrimSpace(section.Category)
+		section.Difficulty = strings.ToLower(strings.TrimSpace(section.Difficulty))
+
+		if section.Count < 1 {
+			return fmt.Errorf("section %d: count must be at least 1", i+1)
+		}
+		if section.Difficulty != "" && !contains(validDifficulties, section.Difficulty) {
+			return fmt.Errorf("section %d: difficulty must be one of: %v", i+1, validDifficulties)
+		}
+		total += section.Count
+	}
+
+	if total > 200 {
+		return fmt.Errorf("a blueprint cannot have more than 200 questions")
+	}
+
+	return nil
+}
+
+// resolveBlueprintCategories replaces the category of each section by its slug, unknown categories
+// would only make the paper impossible to build
+func (eh *ExamHandlers) resolveBlueprintCategories(req *models.ExamBlueprintRequest) error {
+	for i := range req.Sections {
+		if req.Sections[i].Category == "" {
+			continue
+		}
+		slugs, err := eh.db.ResolveCategories([]string{req.Sections[i].Category})
+		if err != nil {
+			return fmt.Errorf("section %d: %v", i+1, err)
+		}
+		req.Sections[i].Category = slugs[0]
+	}
+	return nil
+}
+
+func (eh *ExamHandlers) writeExamError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "name already exists"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "not enough approved questions"):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case strings.Contains(err.Error(), "time limit has expired"),
+		strings.Contains(err.Error(), "already submitted"),
+		strings.Contains(err.Error(), "still in progress"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "not part of this exam"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
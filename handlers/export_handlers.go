@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// exportWriteTimeout replaces the server WriteTimeout for exports, a large bank takes longer to stream
+const exportWriteTimeout = 10 * time.Minute
+
+// ExportQuestions handles GET /export?format=json|csv|anki with optional category, difficulty and
+// status filters. The JSON export is a models.ImportRequest body that POST /import accepts back.
+func (qh *QuestionHandlers) ExportQuestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.LogHTTP("Method %s not allowed for /export", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.QuestionExportFilter{
+		Category:   strings.TrimSpace(query.Get("category")),
+		Difficulty: strings.ToLower(strings.TrimSpace(query.Get("difficulty"))),
+		Status:     strings.ToLower(strings.TrimSpace(query.Get("status"))),
+	}
+
+	switch filter.Difficulty {
+	case "", "easy", "medium", "hard":
+	default:
+		http.Error(w, "difficulty must be easy, medium or hard", http.StatusBadRequest)
+		return
+	}
+
+	switch filter.Status {
+	case "", "pending", "approved", "rejected":
+	default:
+		http.Error(w, "status must be pending, approved or rejected", http.StatusBadRequest)
+		return
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = "json"
+	}
+
+	separator := utils.DefaultCSVListSeparator
+	if v := query.Get("list_separator"); v != "" {
+		if v == "," || strings.ContainsAny(v, "\"\r\n") {
+			http.Error(w, "list_separator cannot be a comma, a quote or a line break", http.StatusBadRequest)
+			return
+		}
+		separator = v
+	}
+
+	var contentType, extension string
+	switch format {
+	case "json":
+		contentType, extension = "application/json", "json"
+	case "csv":
+		contentType, extension = "text/csv; charset=utf-8", "csv"
+	case "anki":
+		contentType, extension = "text/plain; charset=utf-8", "txt"
+	default:
+		http.Error(w, "format must be json, csv or anki", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("Export of questions as %s by %s: %+v", format, session.Username, filter)
+
+	// The server WriteTimeout would cut long exports short
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		utils.LogHTTP("Could not extend the write deadline of the export: %v", err)
+	}
+
+	filename := fmt.Sprintf("questions-%s.%s", time.Now().UTC().Format("20060102-150405"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var count int
+	var err error
+	switch format {
+	case "json":
+		count, err = qh.exportJSON(w, filter)
+	case "csv":
+		count, err = qh.exportCSV(w, filter, separator)
+	case "anki":
+		count, err = qh.exportAnki(w, filter)
+	}
+
+	// Once the first bytes are out the status can no longer change, a truncated file is all the client gets
+	if err != nil {
+		utils.LogError("Export failed after %d questions: %v", count, err)
+		return
+	}
+
+	utils.LogHTTP("Exported %d questions as %s for %s", count, format, session.Username)
+}
+
+func (qh *QuestionHandlers) exportJSON(w http.ResponseWriter, filter models.QuestionExportFilter) (int, error) {
+	if _, err := w.Write([]byte("{\"questions\":[\n")); err != nil {
+		return 0, err
+	}
+
+	encoder := json.NewEncoder(w)
+	first := true
+	count, err := qh.db.ExportQuestions(filter, func(q *models.Question) error {
+		if !first {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		first = false
+		return encoder.Encode(q.ImportForm())
+	})
+	if err != nil {
+		return count, err
+	}
+
+	_, err = w.Write([]byte("]}\n"))
+	return count, err
+}
+
+func (qh *QuestionHandlers) exportCSV(w http.ResponseWriter, filter models.QuestionExportFilter, separator string) (int, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(utils.QuestionCSVColumns); err != nil {
+		return 0, err
+	}
+
+	count, err := qh.db.ExportQuestions(filter, func(q *models.Question) error {
+		return writer.Write(utils.QuestionCSVRecord(q, separator))
+	})
+	if err != nil {
+		return count, err
+	}
+
+	writer.Flush()
+	return count, writer.Error()
+}
+
+func (qh *QuestionHandlers) exportAnki(w http.ResponseWriter, filter models.QuestionExportFilter) (int, error) {
+	if _, err := w.Write([]byte(utils.AnkiDeckHeader("QuizzApi"))); err != nil {
+		return 0, err
+	}
+
+	return qh.db.ExportQuestions(filter, func(q *models.Question) error {
+		_, err := w.Write([]byte(utils.AnkiNote(q)))
+		return err
+	})
+}
@@ -18,20 +18,30 @@ type API struct {
 	questionHandlers    *QuestionHandlers
 	progressHandlers    *ProgressHandlers
 	preferencesHandlers *PreferencesHandlers
+	practiceHandlers    *PracticeHandlers
+	examHandlers        *ExamHandlers
+	moderationHandlers  *ModerationHandlers
+	categoryHandlers    *CategoryHandlers
+	tagHandlers         *TagHandlers
 	jobManager          *jobs.JobManager
 }
 
-func NewAPI(database *db.DB, sessionStore *auth.SessionStore, emailService *auth.EmailService, emailConfig *models.EmailConfig, jobManager *jobs.JobManager) *API {
+func NewAPI(database *db.DB, sessionStore auth.SessionStore, emailService *auth.EmailService, emailConfig *models.EmailConfig, jobManager *jobs.JobManager) *API {
 	return &API{
 		authHandlers:        NewAuthHandlers(database, sessionStore, emailService, emailConfig, jobManager),
-		questionHandlers:    NewQuestionHandlers(database, sessionStore),
+		questionHandlers:    NewQuestionHandlers(database, sessionStore, jobManager),
 		progressHandlers:    NewProgressHandlers(database, sessionStore),
 		preferencesHandlers: NewPreferencesHandlers(database, sessionStore),
+		practiceHandlers:    NewPracticeHandlers(database, sessionStore),
+		examHandlers:        NewExamHandlers(database, sessionStore),
+		moderationHandlers:  NewModerationHandlers(database, sessionStore),
+		categoryHandlers:    NewCategoryHandlers(database, sessionStore),
+		tagHandlers:         NewTagHandlers(database, sessionStore),
 		jobManager:          jobManager,
 	}
 }
 
-func NewRouter(database *db.DB, sessionStore *auth.SessionStore, emailConfig *models.EmailConfig, jobManager *jobs.JobManager, emailService *auth.EmailService) http.Handler {
+func NewRouter(database *db.DB, sessionStore auth.SessionStore, emailConfig *models.EmailConfig, jobManager *jobs.JobManager, emailService *auth.EmailService) http.Handler {
 	// Now we pass the emailService that was created and registered in main.go
 	api := NewAPI(database, sessionStore, emailService, emailConfig, jobManager)
 
@@ -46,49 +56,88 @@ func NewRouter(database *db.DB, sessionStore *auth.SessionStore, emailConfig *mo
 	// Public verification endpoint (no auth required)
 	mux.HandleFunc("/verify-email", api.authHandlers.verifyEmail)
 
+	// Public page behind the link of password reset emails, it posts to /auth/reset-password
+	mux.HandleFunc("/reset-password", api.authHandlers.resetPasswordPage)
+
 	// Preferences routes with auth
 	mux.HandleFunc("/preferences", authMiddlewareWithEmailCheck(api.preferencesHandlers.HandlePreferences, sessionStore, database, emailConfig))
 
 	// Question routes with auth
 	mux.HandleFunc("/questions", authMiddlewareWithEmailCheck(api.questionHandlers.HandleQuestions, sessionStore, database, emailConfig))
 	mux.HandleFunc("/questions/", func(w http.ResponseWriter, r *http.Request) {
-		// Check if this is an approval request
-		if strings.Contains(r.URL.Path, "/approve") {
-			// Parse the question ID from URL
-			path := strings.TrimPrefix(r.URL.Path, "/questions/")
-			parts := strings.Split(path, "/")
-			if len(parts) == 2 && parts[1] == "approve" {
-				if id, err := strconv.Atoi(parts[0]); err == nil {
-					// Require moderator or admin role for approval
-					authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(func(w http.ResponseWriter, r *http.Request) {
-						api.questionHandlers.HandleQuestionApproval(w, r, id)
-					})(w, r)
-					return
-				}
-			}
-			http.Error(w, "Invalid approval path", http.StatusBadRequest)
-		} else {
-			// Regular question by ID handling
-			path := strings.TrimPrefix(r.URL.Path, "/questions/")
-			id, err := strconv.Atoi(path)
-			if err != nil {
-				utils.LogHTTP("Invalid question ID: %s", path)
-				http.Error(w, "Invalid question ID", http.StatusBadRequest)
-				return
-			}
+		// Paths are /questions/{id} followed by an optional action
+		path := strings.TrimPrefix(r.URL.Path, "/questions/")
+		parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			utils.LogHTTP("Invalid question ID: %s", path)
+			http.Error(w, "Invalid question ID", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case len(parts) == 1:
 			authMiddlewareWithEmailCheck(func(w http.ResponseWriter, r *http.Request) {
 				api.questionHandlers.HandleQuestionByID(w, r, id)
 			}, sessionStore, database, emailConfig)(w, r)
+		case len(parts) == 2 && parts[1] == "approve":
+			// Require moderator or admin role for approval
+			authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(func(w http.ResponseWriter, r *http.Request) {
+				api.questionHandlers.HandleQuestionApproval(w, r, id)
+			})(w, r)
+		case len(parts) == 2 && parts[1] == "reviews":
+			authMiddlewareWithEmailCheck(func(w http.ResponseWriter, r *http.Request) {
+				api.questionHandlers.HandleQuestionReviews(w, r, id)
+			}, sessionStore, database, emailConfig)(w, r)
+		case len(parts) == 2 && parts[1] == "comments":
+			authMiddlewareWithEmailCheck(func(w http.ResponseWriter, r *http.Request) {
+				api.questionHandlers.HandleQuestionComments(w, r, id)
+			}, sessionStore, database, emailConfig)(w, r)
+		case len(parts) == 2 && parts[1] == "resubmit":
+			authMiddlewareWithEmailCheck(func(w http.ResponseWriter, r *http.Request) {
+				api.questionHandlers.HandleQuestionResubmit(w, r, id)
+			}, sessionStore, database, emailConfig)(w, r)
+		case parts[1] == "revisions":
+			authMiddlewareWithEmailCheck(func(w http.ResponseWriter, r *http.Request) {
+				api.questionHandlers.HandleQuestionRevisions(w, r, id, parts[2:])
+			}, sessionStore, database, emailConfig)(w, r)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
 		}
 	})
 	mux.HandleFunc("/questions/next", authMiddlewareWithEmailCheck(api.questionHandlers.GetNextQuestions, sessionStore, database, emailConfig))
+	mux.HandleFunc("/questions/search", authMiddlewareWithEmailCheck(api.questionHandlers.HandleQuestionSearch, sessionStore, database, emailConfig))
 
 	// Progress routes with auth
 	mux.HandleFunc("/progress", authMiddlewareWithEmailCheck(api.progressHandlers.HandleProgress, sessionStore, database, emailConfig))
 	mux.HandleFunc("/progress/stats", authMiddlewareWithEmailCheck(api.progressHandlers.GetProgressStats, sessionStore, database, emailConfig))
 
+	// Practice session routes with auth
+	mux.HandleFunc("/practice/sessions", authMiddlewareWithEmailCheck(api.practiceHandlers.HandlePracticeSessions, sessionStore, database, emailConfig))
+	mux.HandleFunc("/practice/sessions/", authMiddlewareWithEmailCheck(api.practiceHandlers.HandlePracticeSessionByID, sessionStore, database, emailConfig))
+
+	// Mock exam routes with auth, blueprint changes are checked for admin in the handlers
+	mux.HandleFunc("/exams/blueprints", authMiddlewareWithEmailCheck(api.examHandlers.HandleBlueprints, sessionStore, database, emailConfig))
+	mux.HandleFunc("/exams/blueprints/", authMiddlewareWithEmailCheck(api.examHandlers.HandleBlueprintByID, sessionStore, database, emailConfig))
+	mux.HandleFunc("/exams/attempts", authMiddlewareWithEmailCheck(api.examHandlers.HandleAttempts, sessionStore, database, emailConfig))
+	mux.HandleFunc("/exams/attempts/", authMiddlewareWithEmailCheck(api.examHandlers.HandleAttemptByID, sessionStore, database, emailConfig))
+
+	// Moderation routes (moderator and admin)
+	mux.HandleFunc("/moderation/queue", authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(api.moderationHandlers.HandleQueue))
+	mux.HandleFunc("/moderation/queue/", authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(api.moderationHandlers.HandleQueueItem))
+	mux.HandleFunc("/moderation/bulk", authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(api.moderationHandlers.HandleBulk))
+	mux.HandleFunc("/moderation/duplicates", authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(api.moderationHandlers.HandleDuplicates))
+
+	// Category routes with auth, changes are checked for admin in the handlers
+	mux.HandleFunc("/categories", authMiddlewareWithEmailCheck(api.categoryHandlers.HandleCategories, sessionStore, database, emailConfig))
+	mux.HandleFunc("/categories/", authMiddlewareWithEmailCheck(api.categoryHandlers.HandleCategoryByID, sessionStore, database, emailConfig))
+	mux.HandleFunc("/tags", authMiddlewareWithEmailCheck(api.tagHandlers.HandleTags, sessionStore, database, emailConfig))
+	mux.HandleFunc("/tags/", authMiddlewareWithEmailCheck(api.tagHandlers.HandleTagByID, sessionStore, database, emailConfig))
+
 	// Import/Export routes (require auth)
 	mux.HandleFunc("/import", authMiddlewareWithEmailCheck(api.questionHandlers.ImportQuestions, sessionStore, database, emailConfig))
+	mux.HandleFunc("/import/", authMiddlewareWithEmailCheck(api.questionHandlers.HandleImportJob, sessionStore, database, emailConfig))
+	mux.HandleFunc("/export", authMiddlewareWithRoleCheck([]string{"moderator", "admin"}, sessionStore, database, emailConfig)(api.questionHandlers.ExportQuestions))
 
 	// User management routes (admin and moderator)
 	mux.HandleFunc("/users", authMiddlewareWithRoleCheck([]string{"admin", "moderator"}, sessionStore, database, emailConfig)(api.authHandlers.HandleUsers))
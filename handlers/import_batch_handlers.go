@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// HandleImportBatches serves /import/batches and its sub-paths:
+//
+//	GET  /import/batches                 batches, newest first, only their own for regular users
+//	GET  /import/batches/{id}            a single batch with its question counts
+//	POST /import/batches/{id}/rollback   admins only, deletes every question of the batch
+func (qh *QuestionHandlers) HandleImportBatches(w http.ResponseWriter, r *http.Request, subPath string) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var parts []string
+	if subPath != "" {
+		parts = strings.Split(subPath, "/")
+	}
+
+	switch {
+	case len(parts) == 0:
+		qh.listImportBatches(w, r, session)
+	case len(parts) == 1:
+		qh.getImportBatch(w, r, session, parts[0])
+	case len(parts) == 2 && parts[1] == "rollback":
+		qh.rollbackImportBatch(w, r, session, parts[0])
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (qh *QuestionHandlers) listImportBatches(w http.ResponseWriter, r *http.Request, session *models.Session) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	importedBy := 0
+	if !session.CanApproveQuestions() {
+		importedBy = session.UserID
+	} else if param := r.URL.Query().Get("imported_by"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid imported_by", http.StatusBadRequest)
+			return
+		}
+		importedBy = id
+	}
+
+	batches, err := qh.db.ListImportBatches(importedBy)
+	if err != nil {
+		http.Error(w, "Failed to fetch import batches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"batches": batches,
+		"count":   len(batches),
+	})
+}
+
+func (qh *QuestionHandlers) getImportBatch(w http.ResponseWriter, r *http.Request, session *models.Session, idParam string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	batchID, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, "Invalid import batch ID", http.StatusBadRequest)
+		return
+	}
+
+	batch, err := qh.db.GetImportBatch(batchID)
+	if err != nil {
+		writeImportBatchError(w, err, "Failed to fetch import batch")
+		return
+	}
+
+	// Other users' batches are hidden rather than forbidden, like their import jobs
+	if batch.ImportedBy != session.UserID && !session.CanApproveQuestions() {
+		http.Error(w, "Import batch not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
+func (qh *QuestionHandlers) rollbackImportBatch(w http.ResponseWriter, r *http.Request, session *models.Session, idParam string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !session.CanRollbackImports() {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	batchID, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, "Invalid import batch ID", http.StatusBadRequest)
+		return
+	}
+
+	rollback, err := qh.db.RollbackImportBatch(batchID, session.UserID)
+	if err != nil {
+		writeImportBatchError(w, err, "Failed to roll back import batch")
+		return
+	}
+
+	utils.LogHTTP("Import batch %d rolled back by %s: %d questions deleted", batchID, session.Username, rollback.DeletedQuestions)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rollback)
+}
+
+func writeImportBatchError(w http.ResponseWriter, err error, fallback string) {
+	switch err.Error() {
+	case "import batch not found":
+		http.Error(w, "Import batch not found", http.StatusNotFound)
+	case "import batch already rolled back":
+		http.Error(w, "Import batch already rolled back", http.StatusConflict)
+	case "import batch is still being imported":
+		http.Error(w, "Import batch is still being imported, retry once its import job is done", http.StatusConflict)
+	default:
+		utils.LogError("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
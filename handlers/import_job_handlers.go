@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// importUploadTimeout replaces the server ReadTimeout while a large import file is uploaded
+const importUploadTimeout = 30 * time.Minute
+
+// queueImportJob handles POST /import?async=true: the file, sent as the raw body or as the "file"
+// field of a multipart form, is stored on disk and processed by the job queue. The response points
+// at GET /import/{job_id}.
+func (qh *QuestionHandlers) queueImportJob(w http.ResponseWriter, r *http.Request, session *models.Session) {
+	if qh.jobManager == nil {
+		http.Error(w, "Asynchronous imports are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		http.Error(w, "dry_run is not available for asynchronous imports", http.StatusBadRequest)
+		return
+	}
+
+	// The server ReadTimeout would cut long uploads short
+	if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(importUploadTimeout)); err != nil {
+		utils.LogHTTP("Could not extend the read deadline of the upload: %v", err)
+	}
+
+	body := io.Reader(r.Body)
+	filename := r.URL.Query().Get("filename")
+	if strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "multipart/form-data") {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, "Invalid multipart body", http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				http.Error(w, "Multipart body has no 'file' field", http.StatusBadRequest)
+				return
+			}
+			if part.FormName() == "file" {
+				body, filename = part, part.FileName()
+				break
+			}
+		}
+	}
+	filename = importFilename(filename)
+
+	format, err := importFormat(r, filename)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job := &models.ImportJob{
+		CreatedBy:           session.UserID,
+		Format:              format,
+		Delimiter:           ",",
+		ListSeparator:       utils.DefaultCSVListSeparator,
+		AllowNearDuplicates: r.URL.Query().Get("allow_near_duplicates") == "true",
+		SourceFilename:      filename,
+	}
+	if format != "json" {
+		delimiter, separator, err := importCSVOptions(r, format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job.Delimiter, job.ListSeparator = string(delimiter), separator
+	}
+
+	dir := utils.GetEnvOrDefault("IMPORT_DIR", filepath.Join(os.TempDir(), "quizzapi-imports"))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		utils.LogError("Failed to create import directory %s: %v", dir, err)
+		http.Error(w, "Failed to store the import file", http.StatusInternalServerError)
+		return
+	}
+
+	file, err := os.CreateTemp(dir, "import-*."+format)
+	if err != nil {
+		utils.LogError("Failed to create import file: %v", err)
+		http.Error(w, "Failed to store the import file", http.StatusInternalServerError)
+		return
+	}
+	job.FilePath = file.Name()
+
+	job.FileSize, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(job.FilePath)
+		utils.LogError("Failed to receive import file: %v", err)
+		http.Error(w, "Failed to receive the import file", http.StatusBadRequest)
+		return
+	}
+	if job.FileSize == 0 {
+		os.Remove(job.FilePath)
+		http.Error(w, "The import file is empty", http.StatusBadRequest)
+		return
+	}
+
+	jobID, err := qh.db.CreateImportJob(job)
+	if err != nil {
+		os.Remove(job.FilePath)
+		http.Error(w, "Failed to create import job", http.StatusInternalServerError)
+		return
+	}
+
+	if err := qh.jobManager.QueueImport(jobID); err != nil {
+		utils.LogError("Failed to queue import job %d: %v", jobID, err)
+		os.Remove(job.FilePath)
+		qh.db.FinishImportJob(jobID, "could not be queued")
+		http.Error(w, "Job queue unavailable, try again later", http.StatusServiceUnavailable)
+		return
+	}
+
+	queued, err := qh.db.GetImportJob(jobID)
+	if err != nil {
+		http.Error(w, "Failed to fetch import job", http.StatusInternalServerError)
+		return
+	}
+
+	utils.LogImport("Queued import job %d for %s: %s file %q (%d bytes)", jobID, session.Username, format, filename, job.FileSize)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/import/%d", jobID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(queued)
+}
+
+// importFilename keeps the base name of an uploaded file, which is all the import records of it
+func importFilename(name string) string {
+	name = filepath.Base(name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// HandleImportJob handles GET /import/{job_id}, the status, counts and errors of an asynchronous import,
+// and hands /import/batches over to HandleImportBatches
+func (qh *QuestionHandlers) HandleImportJob(w http.ResponseWriter, r *http.Request) {
+	idParam := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/import/"), "/")
+	utils.LogHTTP("%s /import/%s", r.Method, idParam)
+
+	if idParam == "batches" || strings.HasPrefix(idParam, "batches/") {
+		qh.HandleImportBatches(w, r, strings.TrimPrefix(strings.TrimPrefix(idParam, "batches"), "/"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, "Invalid import job ID", http.StatusBadRequest)
+		return
+	}
+
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	job, err := qh.db.GetImportJob(jobID)
+	if err != nil {
+		if err.Error() == "import job not found" {
+			http.Error(w, "Import job not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch import job", http.StatusInternalServerError)
+		return
+	}
+
+	// Other users' jobs are hidden rather than forbidden
+	if job.CreatedBy != session.UserID && !session.CanApproveQuestions() {
+		http.Error(w, "Import job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
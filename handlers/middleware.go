@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -32,8 +34,81 @@ func extractSessionFromRequest(r *http.Request) string {
 	return cookie.Value
 }
 
+// trustedProxies are the networks whose X-Forwarded-For header is believed, none by default
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies parses a comma separated list of proxy IPs or CIDRs, as given in TRUSTED_PROXIES
+func SetTrustedProxies(list string) error {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy: %s", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy: %s", entry)
+		}
+		networks = append(networks, network)
+	}
+	trustedProxies = networks
+	return nil
+}
+
+func isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the caller address. X-Forwarded-For is only read when the request comes from a
+// trusted proxy, and then the right-most address not belonging to one is taken, since anything to
+// its left was written by the client itself.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(host) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
 // authMiddleware validates session and adds user context
-func authMiddleware(sessionStore *auth.SessionStore) func(http.HandlerFunc) http.HandlerFunc {
+func authMiddleware(sessionStore auth.SessionStore) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			sessionID := extractSessionFromRequest(r)
@@ -56,7 +131,7 @@ func authMiddleware(sessionStore *auth.SessionStore) func(http.HandlerFunc) http
 }
 
 // authMiddlewareWithEmailCheck validates session and checks email verification during grace period
-func authMiddlewareWithEmailCheck(next http.HandlerFunc, sessionStore *auth.SessionStore, database *db.DB, emailConfig *models.EmailConfig) http.HandlerFunc {
+func authMiddlewareWithEmailCheck(next http.HandlerFunc, sessionStore auth.SessionStore, database *db.DB, emailConfig *models.EmailConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sessionID := extractSessionFromRequest(r)
 		if sessionID == "" {
@@ -100,7 +175,7 @@ func authMiddlewareWithEmailCheck(next http.HandlerFunc, sessionStore *auth.Sess
 }
 
 // optionalAuthMiddleware validates session if present, but doesn't require it
-func optionalAuthMiddleware(sessionStore *auth.SessionStore) func(http.HandlerFunc) http.HandlerFunc {
+func optionalAuthMiddleware(sessionStore auth.SessionStore) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			sessionID := extractSessionFromRequest(r)
@@ -115,7 +190,7 @@ func optionalAuthMiddleware(sessionStore *auth.SessionStore) func(http.HandlerFu
 	}
 }
 
-func authMiddlewareWithRoleCheck(requiredRoles []string, sessionStore *auth.SessionStore, database *db.DB, emailConfig *models.EmailConfig) func(http.HandlerFunc) http.HandlerFunc {
+func authMiddlewareWithRoleCheck(requiredRoles []string, sessionStore auth.SessionStore, database *db.DB, emailConfig *models.EmailConfig) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			sessionID := extractSessionFromRequest(r)
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/auth"
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+type ModerationHandlers struct {
+	db           *db.DB
+	sessionStore auth.SessionStore
+}
+
+func NewModerationHandlers(database *db.DB, sessionStore auth.SessionStore) *ModerationHandlers {
+	return &ModerationHandlers{
+		db:           database,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandleQueue handles GET /moderation/queue
+func (mh *ModerationHandlers) HandleQueue(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /moderation/queue", r.Method)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseModerationQueueFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, total, err := mh.db.GetModerationQueue(filter, session.UserID)
+	if err != nil {
+		http.Error(w, "Failed to fetch moderation queue", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"questions": items,
+		"count":     len(items),
+		"total":     total,
+		"limit":     filter.Limit,
+		"offset":    filter.Offset,
+	})
+}
+
+// HandleQueueItem handles POST /moderation/queue/{id}/claim, /assign and /release
+func (mh *ModerationHandlers) HandleQueueItem(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/moderation/queue/")
+	parts := strings.Split(path, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		utils.LogHTTP("Invalid question ID: %s", parts[0])
+		http.Error(w, "Invalid question ID", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("%s /moderation/queue/%s", r.Method, path)
+
+	if len(parts) != 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	switch parts[1] {
+	case "claim":
+		err = mh.db.ClaimQuestion(id, session.UserID)
+	case "assign":
+		var req models.AssignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.LogHTTP("Invalid JSON in assign request: %v", err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if req.ModeratorID <= 0 {
+			http.Error(w, "moderator_id is required", http.StatusBadRequest)
+			return
+		}
+		err = mh.db.AssignQuestion(id, req.ModeratorID, session.UserID)
+	case "release":
+		// Admins may release a question claimed by someone else
+		err = mh.db.ReleaseQuestion(id, session.UserID, session.Role == "admin")
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		mh.writeModerationError(w, err, "Failed to update question assignment")
+		return
+	}
+
+	utils.LogHTTP("Question ID %d %s by %s", id, parts[1], session.Username)
+
+	question, err := mh.db.GetQuestionByID(id)
+	if err != nil {
+		http.Error(w, "Failed to fetch question", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(question)
+}
+
+// HandleBulk handles POST /moderation/bulk
+func (mh *ModerationHandlers) HandleBulk(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /moderation/bulk", r.Method)
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.BulkModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in bulk moderation request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	result, err := mh.db.BulkModerate(session.UserID, req.Decisions)
+	if err != nil {
+		mh.writeModerationError(w, err, "Failed to apply moderation decisions")
+		return
+	}
+
+	utils.LogHTTP("Bulk moderation by %s: %d/%d decisions succeeded (applied: %t)",
+		session.Username, result.Succeeded, result.Total, result.Applied)
+
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Applied {
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleDuplicates handles GET /moderation/duplicates, the clusters of near-identical questions in the bank
+func (mh *ModerationHandlers) HandleDuplicates(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /moderation/duplicates", r.Method)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	threshold := utils.DuplicateThreshold
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, "threshold must be a number", http.StatusBadRequest)
+			return
+		}
+		threshold = parsed
+	}
+
+	clusters, err := mh.db.GetDuplicateClusters(threshold)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid threshold") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to compute duplicate clusters", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"threshold": threshold,
+		"clusters":  clusters,
+		"count":     len(clusters),
+	})
+}
+
+// parseModerationQueueFilter reads the queue filters from the query string
+func parseModerationQueueFilter(r *http.Request) (models.ModerationQueueFilter, error) {
+	query := r.URL.Query()
+	filter := models.ModerationQueueFilter{
+		Category:   strings.TrimSpace(query.Get("category")),
+		Creator:    strings.TrimSpace(query.Get("creator")),
+		Assignment: query.Get("assignment"),
+		Limit:      50,
+	}
+
+	switch filter.Assignment {
+	case "", "unassigned", "mine", "assigned":
+	default:
+		return filter, fmt.Errorf("assignment must be one of: unassigned, mine, assigned")
+	}
+
+	if v := query.Get("created_by"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("created_by must be a user ID")
+		}
+		filter.CreatedBy = id
+	}
+
+	if v := query.Get("min_age_hours"); v != "" {
+		hours, err := strconv.ParseFloat(v, 64)
+		if err != nil || hours < 0 {
+			return filter, fmt.Errorf("min_age_hours must be a positive number")
+		}
+		filter.MinAgeHours = hours
+	}
+
+	if v := query.Get("max_age_hours"); v != "" {
+		hours, err := strconv.ParseFloat(v, 64)
+		if err != nil || hours < 0 {
+			return filter, fmt.Errorf("max_age_hours must be a positive number")
+		}
+		filter.MaxAgeHours = hours
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 200 {
+			return filter, fmt.Errorf("limit must be between 1 and 200")
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("offset must be a positive number")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+func (mh *ModerationHandlers) writeModerationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "claimed by another moderator"),
+		strings.Contains(err.Error(), "not pending approval"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "must be a moderator"),
+		strings.HasPrefix(err.Error(), "invalid bulk request"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/auth"
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+type PracticeHandlers struct {
+	db           *db.DB
+	sessionStore auth.SessionStore
+}
+
+func NewPracticeHandlers(database *db.DB, sessionStore auth.SessionStore) *PracticeHandlers {
+	return &PracticeHandlers{
+		db:           database,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandlePracticeSessions handles POST /practice/sessions
+func (ph *PracticeHandlers) HandlePracticeSessions(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /practice/sessions", r.Method)
+	switch r.Method {
+	case http.MethodPost:
+		ph.startPracticeSession(w, r)
+	default:
+		utils.LogHTTP("Method %s not allowed for /practice/sessions", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandlePracticeSessionByID handles /practice/sessions/{id}, /answers and /finish
+func (ph *PracticeHandlers) HandlePracticeSessionByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/practice/sessions/")
+	parts := strings.Split(path, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		utils.LogHTTP("Invalid practice session ID: %s", parts[0])
+		http.Error(w, "Invalid practice session ID", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("%s /practice/sessions/%s", r.Method, path)
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		ph.getPracticeSession(w, r, id)
+	case len(parts) == 2 && parts[1] == "answers" && r.Method == http.MethodPost:
+		ph.submitPracticeAnswer(w, r, id)
+	case len(parts) == 2 && parts[1] == "finish" && r.Method == http.MethodPost:
+		ph.finishPracticeSession(w, r, id)
+	case len(parts) <= 2:
+		utils.LogHTTP("Method %s not allowed for /practice/sessions/%s", r.Method, path)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (ph *PracticeHandlers) startPracticeSession(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional; without a count the user's preferred session length is used
+	var req struct {
+		Count int `json:"count"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.LogHTTP("Invalid JSON in practice session request: %v", err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Count < 0 || req.Count > 50 {
+		http.Error(w, "Count must be between 0 and 50 (0 uses your preferred session length)", http.StatusBadRequest)
+		return
+	}
+
+	practice, questions, err := ph.db.StartPracticeSession(session.UserID, req.Count)
+	if err != nil {
+		if strings.Contains(err.Error(), "no questions available") {
+			http.Error(w, "No questions available for practice", http.StatusNotFound)
+			return
+		}
+		utils.LogError("Failed to start practice session: %v", err)
+		http.Error(w, "Failed to start practice session", http.StatusInternalServerError)
+		return
+	}
+
+	utils.LogHTTP("Practice session %d started for user %s with %d questions", practice.ID, session.Username, len(questions))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session":   practice,
+		"questions": questionsForSession(session, questions),
+		"count":     len(questions),
+	})
+}
+
+func (ph *PracticeHandlers) getPracticeSession(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	practice, err := ph.db.GetPracticeSession(id, session.UserID)
+	if err != nil {
+		ph.writePracticeError(w, err, "Failed to fetch practice session")
+		return
+	}
+
+	// A finished session is reviewed in full; an active one only shows its questions
+	if practice.Status == "finished" {
+		review, err := ph.db.FinishPracticeSession(session.UserID, id)
+		if err != nil {
+			ph.writePracticeError(w, err, "Failed to fetch practice session")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(review)
+		return
+	}
+
+	questions, err := ph.db.GetPracticeSessionQuestions(practice)
+	if err != nil {
+		utils.LogError("Failed to load questions of practice session %d: %v", id, err)
+		http.Error(w, "Failed to fetch practice session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session":   practice,
+		"questions": questionsForSession(session, questions),
+		"count":     len(questions),
+	})
+}
+
+func (ph *PracticeHandlers) submitPracticeAnswer(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ProgressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in practice answer: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.QuestionID == 0 || req.UserAnswer == "" {
+		utils.LogHTTP("Missing required fields in practice answer")
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	result, err := ph.db.RecordPracticeAnswer(session.UserID, session.Role, id, req)
+	if err != nil {
+		ph.writePracticeError(w, err, "Failed to record answer")
+		return
+	}
+
+	practice, err := ph.db.GetPracticeSession(id, session.UserID)
+	if err != nil {
+		ph.writePracticeError(w, err, "Failed to record answer")
+		return
+	}
+
+	utils.LogHTTP("Practice answer recorded: session %d, question %d, correct: %t", id, req.QuestionID, result.IsCorrect)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	// In end_of_session mode the outcome is only revealed when the session is finished
+	if practice.ReviewMode == "end_of_session" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":                  result.ID,
+			"question_id":         result.QuestionID,
+			"practice_session_id": id,
+			"answered":            practice.Answered,
+			"total":               len(practice.QuestionIDs),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func (ph *PracticeHandlers) finishPracticeSession(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	review, err := ph.db.FinishPracticeSession(session.UserID, id)
+	if err != nil {
+		ph.writePracticeError(w, err, "Failed to finish practice session")
+		return
+	}
+
+	utils.LogHTTP("Practice session %d finished for user %s: %d/%d correct", id, session.Username, review.Correct, review.Total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+func (ph *PracticeHandlers) writePracticeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "practice session not found"):
+		http.Error(w, "Practice session not found", http.StatusNotFound)
+	case err.Error() == "question not found":
+		http.Error(w, "Question not found", http.StatusNotFound)
+	case strings.Contains(err.Error(), "already finished"),
+		strings.Contains(err.Error(), "already answered"),
+		strings.Contains(err.Error(), "exam in progress"),
+		strings.Contains(err.Error(), "practice session in progress"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "not part of this practice session"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -13,10 +13,10 @@ import (
 
 type PreferencesHandlers struct {
 	db           *db.DB
-	sessionStore *auth.SessionStore
+	sessionStore auth.SessionStore
 }
 
-func NewPreferencesHandlers(database *db.DB, sessionStore *auth.SessionStore) *PreferencesHandlers {
+func NewPreferencesHandlers(database *db.DB, sessionStore auth.SessionStore) *PreferencesHandlers {
 	return &PreferencesHandlers{
 		db:           database,
 		sessionStore: sessionStore,
@@ -121,14 +121,28 @@ func (ph *PreferencesHandlers) validatePreferencesRequest(req *models.UserPrefer
 		}
 	}
 
+	if req.SelectionMode != nil {
+		validModes := []string{"smart", "spaced_repetition"}
+		if !contains(validModes, *req.SelectionMode) {
+			return fmt.Errorf("selection_mode must be one of: %v", validModes)
+		}
+	}
+
 	if req.CategoryPreference != nil && len(*req.CategoryPreference) > 0 {
-		// Validate that all categories exist (optional - you could skip this)
-		validCategories := []string{"symboles", "personnalités", "politique", "histoire", "laïcité", "valeurs", "société", "citoyenneté", "patrimoine", "culture", "géographie", "europe", "sciences"}
-		for _, category := range *req.CategoryPreference {
-			if !contains(validCategories, category) {
-				return fmt.Errorf("invalid category: %s", category)
-			}
+		// Categories are matched by slug or name and stored as slugs
+		slugs, err := ph.db.ResolveCategories(*req.CategoryPreference)
+		if err != nil {
+			return err
+		}
+		*req.CategoryPreference = slugs
+	}
+
+	if req.TagPreference != nil && len(*req.TagPreference) > 0 {
+		slugs, err := ph.db.ResolveTags(*req.TagPreference)
+		if err != nil {
+			return err
 		}
+		*req.TagPreference = slugs
 	}
 
 	return nil
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/adamspd/QuizzApi/auth"
 	"github.com/adamspd/QuizzApi/db"
@@ -12,10 +13,10 @@ import (
 
 type ProgressHandlers struct {
 	db           *db.DB
-	sessionStore *auth.SessionStore
+	sessionStore auth.SessionStore
 }
 
-func NewProgressHandlers(database *db.DB, sessionStore *auth.SessionStore) *ProgressHandlers {
+func NewProgressHandlers(database *db.DB, sessionStore auth.SessionStore) *ProgressHandlers {
 	return &ProgressHandlers{
 		db:           database,
 		sessionStore: sessionStore,
@@ -54,8 +55,16 @@ func (ph *ProgressHandlers) recordProgress(w http.ResponseWriter, r *http.Reques
 	}
 
 	utils.LogHTTP("Recording progress for user %d, question %d", session.UserID, req.QuestionID)
-	progress, err := ph.db.RecordProgress(session.UserID, req)
+	progress, err := ph.db.RecordProgress(session.UserID, session.Role, req)
 	if err != nil {
+		if strings.Contains(err.Error(), "exam in progress") || strings.Contains(err.Error(), "practice session in progress") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err.Error() == "question not found" {
+			http.Error(w, "Question not found", http.StatusNotFound)
+			return
+		}
 		utils.LogError("Failed to record progress: %v", err)
 		http.Error(w, "Failed to record progress", http.StatusInternalServerError)
 		return
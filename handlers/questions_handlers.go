@@ -2,24 +2,31 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/adamspd/QuizzApi/auth"
 	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/jobs"
 	"github.com/adamspd/QuizzApi/models"
 	"github.com/adamspd/QuizzApi/utils"
 )
 
 type QuestionHandlers struct {
 	db           *db.DB
-	sessionStore *auth.SessionStore
+	sessionStore auth.SessionStore
+	jobManager   *jobs.JobManager
 }
 
-func NewQuestionHandlers(database *db.DB, sessionStore *auth.SessionStore) *QuestionHandlers {
+func NewQuestionHandlers(database *db.DB, sessionStore auth.SessionStore, jobManager *jobs.JobManager) *QuestionHandlers {
 	return &QuestionHandlers{
 		db:           database,
 		sessionStore: sessionStore,
+		jobManager:   jobManager,
 	}
 }
 
@@ -58,18 +65,113 @@ func (qh *QuestionHandlers) getQuestionsWithAuth(w http.ResponseWriter, r *http.
 		return
 	}
 
-	questions, err := qh.db.GetAllQuestionsForUser(session.UserID, session.Role)
+	filter, err := parseQuestionListFilter(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := qh.db.ListQuestions(session.UserID, session.Role, filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.LogError("Failed to fetch questions: %v", err)
 		http.Error(w, "Failed to fetch questions", http.StatusInternalServerError)
 		return
 	}
 
-	utils.LogHTTP("Returning %d questions for user %s", len(questions), session.Username)
+	utils.LogHTTP("Returning %d of %d questions for user %s", len(page.Questions), page.Total, session.Username)
+	response := map[string]interface{}{
+		"questions":   questionsForSession(session, page.Questions),
+		"count":       len(page.Questions),
+		"total":       page.Total,
+		"next_cursor": nil,
+	}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"questions": questions,
-	})
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseQuestionListFilter reads the filters, sort and pagination of GET /questions from the query string
+func parseQuestionListFilter(r *http.Request) (models.QuestionListFilter, error) {
+	query := r.URL.Query()
+	filter := models.QuestionListFilter{
+		Category:     strings.TrimSpace(query.Get("category")),
+		Difficulty:   strings.ToLower(strings.TrimSpace(query.Get("difficulty"))),
+		QuestionType: strings.ToLower(strings.TrimSpace(query.Get("question_type"))),
+		Status:       strings.ToLower(strings.TrimSpace(query.Get("status"))),
+		Search:       strings.TrimSpace(query.Get("search")),
+		Sort:         query.Get("sort"),
+		Order:        strings.ToLower(query.Get("order")),
+		Cursor:       query.Get("cursor"),
+		Limit:        50,
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+	}
+
+	switch filter.Order {
+	case "":
+		filter.Order = "desc"
+	case "asc", "desc":
+	default:
+		return filter, fmt.Errorf("order must be 'asc' or 'desc'")
+	}
+
+	switch filter.Difficulty {
+	case "", "easy", "medium", "hard":
+	default:
+		return filter, fmt.Errorf("difficulty must be easy, medium or hard")
+	}
+
+	if filter.QuestionType != "" && !utils.IsValidQuestionType(filter.QuestionType) {
+		return filter, fmt.Errorf("question_type must be one of: %v", utils.QuestionTypes)
+	}
+
+	switch filter.Status {
+	case "", "pending", "approved", "rejected":
+	default:
+		return filter, fmt.Errorf("status must be pending, approved or rejected")
+	}
+
+	if v := query.Get("created_by"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("created_by must be a user ID")
+		}
+		filter.CreatedBy = id
+	}
+
+	if v := query.Get("import_batch_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("import_batch_id must be an import batch ID")
+		}
+		filter.ImportBatchID = id
+	}
+
+	// ?tag= may be repeated, a question must carry every tag
+	for _, tag := range query["tag"] {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 200 {
+			return filter, fmt.Errorf("limit must be between 1 and 200")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
 }
 
 func (qh *QuestionHandlers) createQuestionWithAuth(w http.ResponseWriter, r *http.Request) {
@@ -97,9 +199,29 @@ func (qh *QuestionHandlers) createQuestionWithAuth(w http.ResponseWriter, r *htt
 		req.Status = "pending"
 	}
 
+	// ?force=true stores the question even if it resembles existing ones
+	force := r.URL.Query().Get("force") == "true"
+
 	// Create question using updated function that accepts creator ID
-	question, err := qh.db.CreateQuestionWithAuth(req, session.UserID, session.Role)
+	question, err := qh.db.CreateQuestionWithAuth(req, session.UserID, session.Role, force)
 	if err != nil {
+		var duplicateErr *models.DuplicateQuestionError
+		if errors.As(err, &duplicateErr) {
+			utils.LogHTTP("Question by %s looks like a duplicate of %d existing question(s)", session.Username, len(duplicateErr.Duplicates))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":      duplicateErr.Error(),
+				"duplicates": duplicateErr.Duplicates,
+				"hint":       "retry with ?force=true to create it anyway",
+			})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid question") {
+			utils.LogHTTP("Rejected question: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.LogError("Failed to create question: %v", err)
 		http.Error(w, "Failed to create question", http.StatusInternalServerError)
 		return
@@ -135,6 +257,12 @@ func (qh *QuestionHandlers) getQuestionByIDWithAuth(w http.ResponseWriter, r *ht
 
 	utils.LogHTTP("Returning question ID %d", id)
 	w.Header().Set("Content-Type", "application/json")
+
+	// Learners only get the answer key of the questions they wrote themselves
+	if !session.CanSeeAnswerKey() && question.CreatedBy != session.UserID {
+		json.NewEncoder(w).Encode(question.LearnerView())
+		return
+	}
 	json.NewEncoder(w).Encode(question)
 }
 
@@ -173,6 +301,11 @@ func (qh *QuestionHandlers) updateQuestionWithAuth(w http.ResponseWriter, r *htt
 
 	updatedQuestion, err := qh.db.UpdateQuestionWithAuth(id, req, session.UserID, session.Role)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid question") {
+			utils.LogHTTP("Rejected update of question ID %d: %v", id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.LogError("Failed to update question ID %d: %v", id, err)
 		http.Error(w, "Failed to update question", http.StatusInternalServerError)
 		return
@@ -254,7 +387,7 @@ func (qh *QuestionHandlers) GetNextQuestions(w http.ResponseWriter, r *http.Requ
 	utils.LogHTTP("Returning %d next questions", len(questions))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"questions": questions,
+		"questions": questionsForSession(session, questions),
 		"count":     len(questions),
 	})
 }
@@ -266,12 +399,24 @@ func (qh *QuestionHandlers) ImportQuestions(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	utils.LogImport("Starting question import process")
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
 
-	var importReq models.ImportRequest
-	if err := json.NewDecoder(r.Body).Decode(&importReq); err != nil {
-		utils.LogError("Invalid JSON in import request: %v", err)
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+	utils.LogImport("Starting question import process by %s", session.Username)
+
+	// Large files go through the job queue instead of being imported within the request
+	if r.URL.Query().Get("async") == "true" {
+		qh.queueImportJob(w, r, session)
+		return
+	}
+
+	importReq, err := decodeImportRequest(r)
+	if err != nil {
+		utils.LogError("Invalid import request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -285,22 +430,23 @@ func (qh *QuestionHandlers) ImportQuestions(w http.ResponseWriter, r *http.Reque
 
 	if len(importReq.Questions) > 1000 {
 		utils.LogImport("Too many questions in import request: %d (max 1000)", len(importReq.Questions))
-		http.Error(w, "Too many questions (max 1000 per import)", http.StatusBadRequest)
+		http.Error(w, "Too many questions (max 1000 per import, use ?async=true for larger files)", http.StatusBadRequest)
 		return
 	}
 
-	result, err := qh.db.ImportQuestions(importReq)
+	// Questions imported by anyone but an admin wait for approval like created ones
+	result, err := qh.db.ImportQuestions(importReq, session.UserID, session.Role)
 	if err != nil {
 		utils.LogError("Import failed: %v", err)
 		http.Error(w, "Import failed", http.StatusInternalServerError)
 		return
 	}
 
-	utils.LogImport("Import completed: %d imported, %d skipped, %d errors",
-		result.ImportedQuestions, result.SkippedQuestions, len(result.Errors))
+	utils.LogImport("Import completed: %d imported in batch %d, %d skipped, %d errors",
+		result.ImportedQuestions, result.BatchID, result.SkippedQuestions, len(result.Errors))
 
 	w.Header().Set("Content-Type", "application/json")
-	if result.ImportedQuestions > 0 {
+	if result.ImportedQuestions > 0 && !result.DryRun {
 		w.WriteHeader(http.StatusCreated)
 	} else {
 		w.WriteHeader(http.StatusOK)
@@ -308,6 +454,97 @@ func (qh *QuestionHandlers) ImportQuestions(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(result)
 }
 
+// decodeImportRequest reads an import body: the JSON models.ImportRequest, or a CSV/TSV spreadsheet
+// laid out as utils.QuestionCSVColumns when the Content-Type or ?format= says so. Spreadsheets take
+// their options from the query string: delimiter (CSV only, default ","), list_separator (default "|")
+// and allow_near_duplicates. ?dry_run=true works for every format, ?filename= names the imported file.
+func decodeImportRequest(r *http.Request) (models.ImportRequest, error) {
+	var importReq models.ImportRequest
+
+	filename := importFilename(r.URL.Query().Get("filename"))
+	format, err := importFormat(r, filename)
+	if err != nil {
+		return importReq, err
+	}
+
+	if format == "json" {
+		if err := json.NewDecoder(r.Body).Decode(&importReq); err != nil {
+			return importReq, fmt.Errorf("Invalid JSON format")
+		}
+		if r.URL.Query().Get("dry_run") == "true" {
+			importReq.DryRun = true
+		}
+		importReq.Format, importReq.SourceFilename = format, filename
+		return importReq, nil
+	}
+
+	delimiter, separator, err := importCSVOptions(r, format)
+	if err != nil {
+		return importReq, err
+	}
+
+	questions, err := utils.ParseQuestionCSV(r.Body, delimiter, separator)
+	if err != nil {
+		return importReq, err
+	}
+
+	importReq.Questions = questions
+	importReq.AllowNearDuplicates = r.URL.Query().Get("allow_near_duplicates") == "true"
+	importReq.DryRun = r.URL.Query().Get("dry_run") == "true"
+	importReq.Format, importReq.SourceFilename = format, filename
+	return importReq, nil
+}
+
+// importFormat tells json, csv or tsv from ?format=, then the Content-Type, then the extension of the
+// uploaded file name, JSON being the default
+func importFormat(r *http.Request, filename string) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		contentType := strings.ToLower(r.Header.Get("Content-Type"))
+		extension := strings.ToLower(filepath.Ext(filename))
+		switch {
+		case strings.HasPrefix(contentType, "text/csv"), extension == ".csv":
+			format = "csv"
+		case strings.HasPrefix(contentType, "text/tab-separated-values"), extension == ".tsv":
+			format = "tsv"
+		default:
+			format = "json"
+		}
+	}
+
+	switch format {
+	case "json", "csv", "tsv":
+		return format, nil
+	default:
+		return "", fmt.Errorf("format must be json, csv or tsv")
+	}
+}
+
+// importCSVOptions reads the field delimiter and the list separator of a spreadsheet import
+func importCSVOptions(r *http.Request, format string) (rune, string, error) {
+	delimiter := '\t'
+	if format == "csv" {
+		delimiter = ','
+		if v := r.URL.Query().Get("delimiter"); v != "" {
+			runes := []rune(v)
+			if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+				return 0, "", fmt.Errorf("delimiter must be a single character other than a quote or a line break")
+			}
+			delimiter = runes[0]
+		}
+	}
+
+	separator := utils.DefaultCSVListSeparator
+	if v := r.URL.Query().Get("list_separator"); v != "" {
+		separator = v
+	}
+	if strings.ContainsRune(separator, delimiter) {
+		return 0, "", fmt.Errorf("list_separator cannot contain the delimiter")
+	}
+
+	return delimiter, separator, nil
+}
+
 func (qh *QuestionHandlers) HandleQuestionApproval(w http.ResponseWriter, r *http.Request, questionID int) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -337,6 +574,12 @@ func (qh *QuestionHandlers) HandleQuestionApproval(w http.ResponseWriter, r *htt
 		return
 	}
 
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Action == "reject" && req.Reason == "" {
+		http.Error(w, "A reason is required when rejecting a question", http.StatusBadRequest)
+		return
+	}
+
 	// Get the question
 	question, err := qh.db.GetQuestionByID(questionID)
 	if err != nil {
@@ -350,21 +593,16 @@ func (qh *QuestionHandlers) HandleQuestionApproval(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Update question status
-	var newStatus string
-	if req.Action == "approve" {
-		newStatus = "approved"
-	} else {
-		newStatus = "rejected"
-	}
-
-	_, err = qh.db.Exec(`
-		UPDATE questions 
-		SET status = ?, approved_by = ?, approved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, newStatus, session.UserID, questionID)
-
+	updatedQuestion, err := qh.db.SetQuestionApproval(questionID, session.UserID, req.Action == "approve", req.Reason)
 	if err != nil {
+		if err.Error() == "question is not pending approval" {
+			http.Error(w, "Question is not pending approval", http.StatusBadRequest)
+			return
+		}
+		if err.Error() == "question is claimed by another moderator" {
+			http.Error(w, "Question is claimed by another moderator", http.StatusConflict)
+			return
+		}
 		utils.LogError("Failed to update question approval status: %v", err)
 		http.Error(w, "Failed to update question", http.StatusInternalServerError)
 		return
@@ -373,7 +611,96 @@ func (qh *QuestionHandlers) HandleQuestionApproval(w http.ResponseWriter, r *htt
 	utils.LogHTTP("Question ID %d %s by user %s", questionID, req.Action+"d", session.Username)
 
 	// Return updated question
-	updatedQuestion, _ := qh.db.GetQuestionByID(questionID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedQuestion)
 }
+
+// HandleQuestionSearch handles GET /questions/search?q=...
+func (qh *QuestionHandlers) HandleQuestionSearch(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /questions/search", r.Method)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	if !qh.db.SearchEnabled() {
+		http.Error(w, "Search is not available on this server", http.StatusServiceUnavailable)
+		return
+	}
+
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := 20, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 100 {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			http.Error(w, "offset must be a positive number", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	hits, total, err := qh.db.SearchQuestions(session.UserID, session.Role, text, limit, offset)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid search") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.LogError("Search failed: %v", err)
+		http.Error(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(hits))
+	for i := range hits {
+		var question interface{} = hits[i].Question
+		if !session.CanSeeAnswerKey() {
+			question = hits[i].Question.LearnerView()
+		}
+		results = append(results, map[string]interface{}{
+			"question":   question,
+			"rank":       hits[i].Rank,
+			"highlights": hits[i].Highlights,
+		})
+	}
+
+	utils.LogHTTP("Search %q returned %d of %d results for user %s", text, len(results), total, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   text,
+		"results": results,
+		"count":   len(results),
+		"total":   total,
+	})
+}
+
+// questionsForSession hides answers and keywords from learners, moderators and admins keep the full view
+func questionsForSession(session *models.Session, questions []models.Question) interface{} {
+	if session.CanSeeAnswerKey() {
+		return questions
+	}
+
+	views := make([]models.LearnerQuestion, 0, len(questions))
+	for i := range questions {
+		views = append(views, questions[i].LearnerView())
+	}
+	return views
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// maxCommentLength keeps comment threads readable and bounds the request size
+const maxCommentLength = 2000
+
+// authorizeQuestionThread loads a question for its moderation endpoints, which are open to moderators,
+// admins and the question's author. It writes the error response and returns nils when access is refused.
+func (qh *QuestionHandlers) authorizeQuestionThread(w http.ResponseWriter, r *http.Request, questionID int) (*models.Session, *models.Question) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, nil
+	}
+
+	question, err := qh.db.GetQuestionByID(questionID)
+	if err != nil {
+		utils.LogHTTP("Question ID %d not found: %v", questionID, err)
+		http.Error(w, "Question not found", http.StatusNotFound)
+		return nil, nil
+	}
+
+	if !session.CanApproveQuestions() && question.CreatedBy != session.UserID {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return nil, nil
+	}
+
+	return session, question
+}
+
+// HandleQuestionReviews lists the approve/reject decisions taken on a question with their reasons
+func (qh *QuestionHandlers) HandleQuestionReviews(w http.ResponseWriter, r *http.Request, questionID int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, _ := qh.authorizeQuestionThread(w, r, questionID)
+	if session == nil {
+		return
+	}
+
+	reviews, err := qh.db.GetQuestionReviews(questionID)
+	if err != nil {
+		http.Error(w, "Failed to fetch reviews", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"question_id": questionID,
+		"reviews":     reviews,
+		"count":       len(reviews),
+	})
+}
+
+// HandleQuestionComments reads (GET) or extends (POST) the comment thread of a question
+func (qh *QuestionHandlers) HandleQuestionComments(w http.ResponseWriter, r *http.Request, questionID int) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, _ := qh.authorizeQuestionThread(w, r, questionID)
+	if session == nil {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		comments, err := qh.db.GetQuestionComments(questionID)
+		if err != nil {
+			http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"question_id": questionID,
+			"comments":    comments,
+			"count":       len(comments),
+		})
+		return
+	}
+
+	var req models.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in comment request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		http.Error(w, "Comment body is required", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxCommentLength {
+		http.Error(w, "Comment is too long (max 2000 characters)", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := qh.db.AddQuestionComment(questionID, session.UserID, body)
+	if err != nil {
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+
+	utils.LogHTTP("Comment %d added on question %d by %s", comment.ID, questionID, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// HandleQuestionResubmit sends a rejected question back to the moderation queue
+func (qh *QuestionHandlers) HandleQuestionResubmit(w http.ResponseWriter, r *http.Request, questionID int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, question := qh.authorizeQuestionThread(w, r, questionID)
+	if session == nil {
+		return
+	}
+
+	if question.Status != "rejected" {
+		http.Error(w, "Only rejected questions can be resubmitted", http.StatusConflict)
+		return
+	}
+
+	// The body is optional, an empty request resubmits without a comment
+	var req models.ResubmitRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.LogHTTP("Invalid JSON in resubmit request: %v", err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	req.Comment = strings.TrimSpace(req.Comment)
+	if len(req.Comment) > maxCommentLength {
+		http.Error(w, "Comment is too long (max 2000 characters)", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := qh.db.ResubmitQuestion(questionID, session.UserID, req.Comment)
+	if err != nil {
+		if err.Error() == "only rejected questions can be resubmitted" {
+			http.Error(w, "Only rejected questions can be resubmitted", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to resubmit question", http.StatusInternalServerError)
+		return
+	}
+
+	utils.LogHTTP("Question ID %d resubmitted for approval by %s", questionID, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
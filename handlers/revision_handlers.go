@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+// HandleQuestionRevisions serves /questions/{id}/revisions and its sub-paths:
+//
+//	GET  /questions/{id}/revisions                  history, newest first
+//	GET  /questions/{id}/revisions/diff?from=&to=   field-level diff, to defaults to the latest revision
+//	GET  /questions/{id}/revisions/{rev}            a single revision
+//	POST /questions/{id}/revisions/{rev}/rollback   moderators only, restores the revision as pending
+func (qh *QuestionHandlers) HandleQuestionRevisions(w http.ResponseWriter, r *http.Request, questionID int, subPath []string) {
+	// Revisions carry the answer key, only staff and the author may read them
+	session, _ := qh.authorizeQuestionThread(w, r, questionID)
+	if session == nil {
+		return
+	}
+
+	switch {
+	case len(subPath) == 0:
+		qh.listRevisions(w, r, questionID)
+	case len(subPath) == 1 && subPath[0] == "diff":
+		qh.diffRevisions(w, r, questionID)
+	case len(subPath) == 1:
+		qh.getRevision(w, r, questionID, subPath[0])
+	case len(subPath) == 2 && subPath[1] == "rollback":
+		qh.rollbackToRevision(w, r, session, questionID, subPath[0])
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (qh *QuestionHandlers) listRevisions(w http.ResponseWriter, r *http.Request, questionID int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	revisions, err := qh.db.GetQuestionRevisions(questionID)
+	if err != nil {
+		http.Error(w, "Failed to fetch revisions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"question_id": questionID,
+		"revisions":   revisions,
+		"count":       len(revisions),
+	})
+}
+
+func (qh *QuestionHandlers) getRevision(w http.ResponseWriter, r *http.Request, questionID int, revisionParam string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	revisionNumber, err := strconv.Atoi(revisionParam)
+	if err != nil || revisionNumber < 1 {
+		http.Error(w, "Invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := qh.db.GetQuestionRevision(questionID, revisionNumber)
+	if err != nil {
+		writeRevisionError(w, err, "Failed to fetch revision")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revision)
+}
+
+func (qh *QuestionHandlers) diffRevisions(w http.ResponseWriter, r *http.Request, questionID int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		http.Error(w, "Query parameter 'from' must be a revision number", http.StatusBadRequest)
+		return
+	}
+
+	to := 0
+	if toParam := r.URL.Query().Get("to"); toParam != "" {
+		to, err = strconv.Atoi(toParam)
+		if err != nil || to < 1 {
+			http.Error(w, "Query parameter 'to' must be a revision number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := qh.db.DiffQuestionRevisions(questionID, from, to)
+	if err != nil {
+		writeRevisionError(w, err, "Failed to compare revisions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+func (qh *QuestionHandlers) rollbackToRevision(w http.ResponseWriter, r *http.Request, session *models.Session, questionID int, revisionParam string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !session.CanApproveQuestions() {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	revisionNumber, err := strconv.Atoi(revisionParam)
+	if err != nil || revisionNumber < 1 {
+		http.Error(w, "Invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	question, err := qh.db.RollbackQuestionToRevision(questionID, revisionNumber, session.UserID)
+	if err != nil {
+		writeRevisionError(w, err, "Failed to roll back question")
+		return
+	}
+
+	utils.LogHTTP("Question ID %d rolled back to revision %d by %s, pending approval", questionID, revisionNumber, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(question)
+}
+
+func writeRevisionError(w http.ResponseWriter, err error, fallback string) {
+	if err.Error() == "revision not found" {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return
+	}
+	utils.LogError("%s: %v", fallback, err)
+	http.Error(w, fallback, http.StatusInternalServerError)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adamspd/QuizzApi/auth"
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+)
+
+type TagHandlers struct {
+	db           *db.DB
+	sessionStore auth.SessionStore
+}
+
+func NewTagHandlers(database *db.DB, sessionStore auth.SessionStore) *TagHandlers {
+	return &TagHandlers{
+		db:           database,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandleTags handles GET/POST /tags
+func (th *TagHandlers) HandleTags(w http.ResponseWriter, r *http.Request) {
+	utils.LogHTTP("%s /tags", r.Method)
+	switch r.Method {
+	case http.MethodGet:
+		th.getTags(w, r)
+	case http.MethodPost:
+		th.createTag(w, r)
+	default:
+		utils.LogHTTP("Method %s not allowed for /tags", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTagByID handles GET/PUT/DELETE /tags/{id}
+func (th *TagHandlers) HandleTagByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/tags/")
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		utils.LogHTTP("Invalid tag ID: %s", path)
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	utils.LogHTTP("%s /tags/%d", r.Method, id)
+	switch r.Method {
+	case http.MethodGet:
+		th.getTag(w, r, id)
+	case http.MethodPut:
+		th.updateTag(w, r, id)
+	case http.MethodDelete:
+		th.deleteTag(w, r, id)
+	default:
+		utils.LogHTTP("Method %s not allowed for /tags/%d", r.Method, id)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (th *TagHandlers) getTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := th.db.GetTags()
+	if err != nil {
+		http.Error(w, "Failed to fetch tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tags":  tags,
+		"count": len(tags),
+	})
+}
+
+func (th *TagHandlers) getTag(w http.ResponseWriter, r *http.Request, id int) {
+	tag, err := th.db.GetTag(id)
+	if err != nil {
+		writeTagError(w, err, "Failed to fetch tag")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// createTag handles POST /tags. Tags are curated by moderators and admins, like the questions they group.
+func (th *TagHandlers) createTag(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if !session.CanApproveQuestions() {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	var req models.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in tag request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateTagRequest(&req, true); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tag, err := th.db.CreateTag(req)
+	if err != nil {
+		writeTagError(w, err, "Failed to create tag")
+		return
+	}
+
+	utils.LogHTTP("Tag %d '%s' created by %s", tag.ID, tag.Slug, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (th *TagHandlers) updateTag(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if !session.CanApproveQuestions() {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	var req models.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.LogHTTP("Invalid JSON in tag request: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateTagRequest(&req, false); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tag, err := th.db.UpdateTag(id, req)
+	if err != nil {
+		writeTagError(w, err, "Failed to update tag")
+		return
+	}
+
+	utils.LogHTTP("Tag %d updated by %s", id, session.Username)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// deleteTag handles DELETE /tags/{id}, the tagged questions themselves are kept
+func (th *TagHandlers) deleteTag(w http.ResponseWriter, r *http.Request, id int) {
+	session := getSessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if !session.CanApproveQuestions() {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := th.db.DeleteTag(id); err != nil {
+		writeTagError(w, err, "Failed to delete tag")
+		return
+	}
+
+	utils.LogHTTP("Tag %d deleted by %s", id, session.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateTagRequest(req *models.TagRequest, creating bool) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Slug = strings.TrimSpace(req.Slug)
+
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(req.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+
+	if creating {
+		if req.Slug == "" {
+			req.Slug = utils.CategorySlug(req.Name)
+		}
+		if !utils.IsValidCategorySlug(req.Slug) {
+			return fmt.Errorf("slug must be at most 64 lowercase letters, digits, hyphens or underscores")
+		}
+	}
+
+	return nil
+}
+
+func writeTagError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case err.Error() == "tag not found":
+		http.Error(w, "Tag not found", http.StatusNotFound)
+	case strings.Contains(err.Error(), "already exists"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err.Error() == "tag slug can not be changed":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,221 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adamspd/QuizzApi/db"
+	"github.com/adamspd/QuizzApi/models"
+	"github.com/adamspd/QuizzApi/utils"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TypeImportQuestions = "import:questions"
+
+	// importChunkSize is how many questions are committed, and reported, at a time
+	importChunkSize = 200
+)
+
+type ImportPayload struct {
+	JobID int `json:"job_id"`
+}
+
+// QueueImport schedules the processing of an import job recorded in the database
+func (jm *JobManager) QueueImport(jobID int) error {
+	payloadBytes, err := json.Marshal(ImportPayload{JobID: jobID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal import payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeImportQuestions, payloadBytes)
+	info, err := jm.client.Enqueue(task, asynq.Queue("low"), asynq.MaxRetry(3), asynq.Timeout(2*time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue import task: %w", err)
+	}
+
+	utils.LogInfo("Queued import job: ID=%s import_job=%d", info.ID, jobID)
+	return nil
+}
+
+// countingReader tells how far into the uploaded file the parser is
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func newImportReader(job *models.ImportJob, r io.Reader) (utils.QuestionReader, error) {
+	switch job.Format {
+	case "csv", "tsv":
+		delimiter := []rune(job.Delimiter)
+		if len(delimiter) != 1 {
+			return nil, fmt.Errorf("invalid delimiter %q", job.Delimiter)
+		}
+		return utils.NewQuestionCSVReader(r, delimiter[0], job.ListSeparator)
+	default:
+		return utils.NewQuestionJSONReader(r)
+	}
+}
+
+func (jm *JobManager) handleImportQuestions(database *db.DB) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload ImportPayload
+		if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal import payload: %w: %w", err, asynq.SkipRetry)
+		}
+
+		job, err := database.GetImportJob(payload.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to load import job %d: %w", payload.JobID, err)
+		}
+		if job.Status == "completed" || job.Status == "failed" {
+			return nil
+		}
+
+		utils.LogImport("Processing import job %d: %s file %q (%d bytes), resuming after %d questions",
+			job.ID, job.Format, job.SourceFilename, job.FileSize, job.ProcessedQuestions)
+
+		batchID, err := database.StartImportJob(job.ID)
+		if err != nil {
+			return err
+		}
+
+		err = runImportJob(ctx, database, job, batchID)
+		if err == nil {
+			os.Remove(job.FilePath)
+			return database.FinishImportJob(job.ID, "")
+		}
+
+		// Broken files fail for good, anything else is retried from the last recorded chunk
+		var invalid *invalidImportFile
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		if errors.As(err, &invalid) || retried >= maxRetry {
+			utils.LogError("Import job %d failed: %v", job.ID, err)
+			os.Remove(job.FilePath)
+			if finishErr := database.FinishImportJob(job.ID, err.Error()); finishErr != nil {
+				return finishErr
+			}
+			return fmt.Errorf("import job %d failed: %w: %w", job.ID, err, asynq.SkipRetry)
+		}
+
+		return fmt.Errorf("import job %d interrupted: %w", job.ID, err)
+	}
+}
+
+// invalidImportFile is a problem with the uploaded file itself, its importer or its batch, retrying will not help
+type invalidImportFile struct {
+	err error
+}
+
+func (e *invalidImportFile) Error() string {
+	return e.err.Error()
+}
+
+// runImportJob feeds the file to ImportQuestions chunk by chunk under the job's import batch, recording
+// progress after each one. Questions before the last recorded chunk were committed by an earlier attempt
+// and are skipped.
+func runImportJob(ctx context.Context, database *db.DB, job *models.ImportJob, batchID int) error {
+	// The importer's role decides whether the questions land approved or pending
+	importer, err := database.GetUserByID(job.CreatedBy)
+	if err == sql.ErrNoRows {
+		return &invalidImportFile{fmt.Errorf("the importing user no longer exists")}
+	}
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		return &invalidImportFile{fmt.Errorf("uploaded file is no longer available: %v", err)}
+	}
+	defer file.Close()
+
+	counter := &countingReader{r: file}
+	reader, err := newImportReader(job, counter)
+	if err != nil {
+		return &invalidImportFile{err}
+	}
+
+	processed := job.ProcessedQuestions
+	for skipped := 0; skipped < processed; skipped++ {
+		if _, err := reader.Next(); err != nil {
+			return &invalidImportFile{fmt.Errorf("file changed since the previous attempt: %v", err)}
+		}
+	}
+
+	chunk := make([]models.QuestionImport, 0, importChunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		result, err := database.ImportQuestions(models.ImportRequest{
+			Questions:           chunk,
+			AllowNearDuplicates: job.AllowNearDuplicates,
+			Offset:              processed,
+			Format:              job.Format,
+			SourceFilename:      job.SourceFilename,
+			BatchID:             batchID,
+		}, job.CreatedBy, importer.Role)
+		if err != nil {
+			// Retrying will not bring back a batch that is gone
+			if strings.Contains(err.Error(), "import batch") {
+				return &invalidImportFile{err}
+			}
+			return err
+		}
+
+		processed += len(chunk)
+		chunk = chunk[:0]
+		utils.LogImport("Import job %d: %d questions processed (%d/%d bytes)", job.ID, processed, counter.n, job.FileSize)
+		return database.RecordImportJobChunk(job.ID, result, processed, counter.n)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		q, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Keep what was read before the broken part of the file
+			if flushErr := flush(); flushErr != nil {
+				return flushErr
+			}
+			return &invalidImportFile{fmt.Errorf("stopped after %d questions: %v", processed, err)}
+		}
+
+		chunk = append(chunk, *q)
+		if len(chunk) == importChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if processed == 0 {
+		return &invalidImportFile{fmt.Errorf("no questions found in the file")}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/adamspd/QuizzApi/auth"
+	"github.com/adamspd/QuizzApi/db"
 	"github.com/adamspd/QuizzApi/utils"
 	"github.com/hibiken/asynq"
 )
@@ -60,8 +61,9 @@ func NewJobManager(redisURL string) *JobManager {
 	}
 }
 
-func (jm *JobManager) RegisterHandlers(emailService *auth.EmailService) {
+func (jm *JobManager) RegisterHandlers(emailService *auth.EmailService, database *db.DB) {
 	jm.mux.HandleFunc(TypeSendEmail, jm.handleSendEmail(emailService))
+	jm.mux.HandleFunc(TypeImportQuestions, jm.handleImportQuestions(database))
 }
 
 func (jm *JobManager) Start() error {
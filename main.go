@@ -35,6 +35,16 @@ func main() {
 	dbPath := utils.GetEnvOrDefault("DB_PATH", "./citizenship.db")
 	utils.LogStartup("Using database path: %s", dbPath)
 
+	trustedProxies := utils.GetEnvOrDefault("TRUSTED_PROXIES", "")
+	if err := handlers.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("[FATAL] %v", err)
+	}
+	if trustedProxies == "" {
+		utils.LogStartup("No trusted proxies - X-Forwarded-For is ignored")
+	} else {
+		utils.LogStartup("Trusting X-Forwarded-For from: %s", trustedProxies)
+	}
+
 	// Load email configuration
 	utils.LogStartup("Loading email configuration...")
 	emailConfig := auth.LoadEmailConfig()
@@ -43,6 +53,13 @@ func main() {
 		utils.LogStartup("SMTP not configured - emails will be logged to console")
 	}
 
+	// Load answer matching configuration
+	answerMatcherConfig := utils.LoadAnswerMatcherConfig()
+	utils.SetAnswerMatcherConfig(answerMatcherConfig)
+	utils.LogStartup("Answer matching - accents: %t, punctuation: %t, articles: %t, keywords: %t, max typos: %d",
+		answerMatcherConfig.FoldAccents, answerMatcherConfig.StripPunctuation, answerMatcherConfig.StripArticles,
+		answerMatcherConfig.UseKeywords, answerMatcherConfig.MaxTypos)
+
 	// Initialize database FIRST
 	utils.LogStartup("Initializing database connection...")
 	database, err := db.InitDB(dbPath)
@@ -52,7 +69,15 @@ func main() {
 
 	// Initialize session store
 	utils.LogStartup("Initializing session store...")
-	sessionStore := auth.NewSessionStore()
+	var sessionStore auth.SessionStore
+	switch backend := utils.GetEnvOrDefault("SESSION_STORE", "database"); backend {
+	case "memory":
+		sessionStore = auth.NewMemorySessionStore()
+	case "database":
+		sessionStore = auth.NewDBSessionStore(database)
+	default:
+		log.Fatalf("[FATAL] Unknown SESSION_STORE %q (expected \"database\" or \"memory\")", backend)
+	}
 	utils.LogStartup("Session store initialized with automatic cleanup")
 
 	// Create default admin user if none exists
@@ -66,7 +91,7 @@ func main() {
 
 	// Create email service and register handlers BEFORE starting worker
 	emailService := auth.NewEmailService(emailConfig)
-	jobManager.RegisterHandlers(emailService)
+	jobManager.RegisterHandlers(emailService, database)
 
 	// NOW start the job worker
 	go func() {
@@ -117,7 +142,46 @@ func main() {
 	utils.LogStartup("  POST /auth/login - Login")
 	utils.LogStartup("  POST /auth/logout - Logout")
 	utils.LogStartup("  GET  /auth/me - Get current user info")
+	utils.LogStartup("  GET  /auth/sessions - List your active sessions")
+	utils.LogStartup("  DELETE /auth/sessions/{id} - Revoke one of your sessions")
+	utils.LogStartup("  POST /auth/logout-others - Log out everywhere else")
+	utils.LogStartup("  POST /auth/forgot-password - Request a password reset email")
+	utils.LogStartup("  POST /auth/reset-password - Reset password with emailed token")
 	utils.LogStartup("  GET  /verify-email?token=... - Verify email")
+	utils.LogStartup("Practice endpoints available at:")
+	utils.LogStartup("  POST /practice/sessions - Start a practice session")
+	utils.LogStartup("  GET  /practice/sessions/{id} - Get a practice session")
+	utils.LogStartup("  POST /practice/sessions/{id}/answers - Answer a question of the session")
+	utils.LogStartup("  POST /practice/sessions/{id}/finish - Finish and review the session")
+	utils.LogStartup("Exam endpoints available at:")
+	utils.LogStartup("  GET  /exams/blueprints - List exam blueprints (POST/PUT/DELETE for admins)")
+	utils.LogStartup("  POST /exams/attempts - Start a timed mock exam")
+	utils.LogStartup("  GET  /exams/attempts/{id} - Get an exam attempt or its result")
+	utils.LogStartup("  PUT  /exams/attempts/{id}/answers - Save answers before the deadline")
+	utils.LogStartup("  POST /exams/attempts/{id}/submit - Submit and grade the exam")
+	utils.LogStartup("Category endpoints available at:")
+	utils.LogStartup("  GET  /categories - List categories with their approved question counts (POST for admins)")
+	utils.LogStartup("  GET  /categories/{id} - Get a category (PUT for admins)")
+	utils.LogStartup("  DELETE /categories/{id}?reassign_to= - Delete a category, moving its questions (admin only)")
+	utils.LogStartup("Tag endpoints available at:")
+	utils.LogStartup("  GET  /tags - List tags with their approved question counts (POST for moderators and admins)")
+	utils.LogStartup("  GET  /tags/{id} - Get a tag (PUT and DELETE for moderators and admins)")
+	utils.LogStartup("  GET  /questions?tag=a&tag=b - List questions carrying every given tag")
+	utils.LogStartup("Moderation endpoints available at:")
+	utils.LogStartup("  GET  /moderation/queue - List pending questions (category, creator, age and assignment filters)")
+	utils.LogStartup("  POST /moderation/queue/{id}/claim - Claim a pending question for review")
+	utils.LogStartup("  POST /moderation/queue/{id}/assign - Assign a pending question to a moderator")
+	utils.LogStartup("  POST /moderation/queue/{id}/release - Release a claimed question")
+	utils.LogStartup("  POST /moderation/bulk - Approve or reject several questions at once")
+	utils.LogStartup("  GET  /moderation/duplicates?threshold= - Clusters of near-duplicate questions")
+	utils.LogStartup("Import/Export endpoints available at:")
+	utils.LogStartup("  POST /import - Import questions (JSON, or CSV/TSV with ?format=csv|tsv, delimiter and list_separator; ?dry_run=true only validates)")
+	utils.LogStartup("  POST /import?async=true - Queue a file of any size for import, returns a job")
+	utils.LogStartup("  GET  /import/{job_id} - Status, counts and errors of a queued import")
+	utils.LogStartup("  GET  /import/batches - Import batches with their question counts (own batches for regular users)")
+	utils.LogStartup("  GET  /import/batches/{id} - A single import batch")
+	utils.LogStartup("  POST /import/batches/{id}/rollback - Delete every question of an import batch (admin only)")
+	utils.LogStartup("  GET  /export?format=json|csv|anki - Export questions (category, difficulty and status filters)")
 
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("[FATAL] Server failed to start: %v", err)
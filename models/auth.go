@@ -1,9 +1,6 @@
 package models
 
-import (
-	"sync"
-	"time"
-)
+import "time"
 
 // User represents a user in the system
 type User struct {
@@ -35,18 +32,27 @@ type LoginRequest struct {
 
 // Session represents an active user session
 type Session struct {
-	ID        string    `json:"session_id"`
-	UserID    int       `json:"user_id"`
-	Username  string    `json:"username"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID         string    `json:"session_id"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
 }
 
-// SessionStore manages in-memory sessions
-type SessionStore struct {
-	Sessions map[string]*Session
-	Mutex    sync.RWMutex
+// SessionInfo describes one of a user's sessions without exposing its token.
+// ID is the hash of the token and is what DELETE /auth/sessions/{id} expects.
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
 }
 
 // EmailVerification represents a pending email verification
@@ -60,14 +66,36 @@ type EmailVerification struct {
 	UsedAt    *time.Time `json:"used_at,omitempty"`
 }
 
+// PasswordReset represents a pending password reset, only the token hash is persisted
+type PasswordReset struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Token     string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// ForgotPasswordRequest starts the password reset flow
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest completes the password reset flow
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
 // EmailConfig holds SMTP configuration
 type EmailConfig struct {
-	SMTPHost    string
-	SMTPPort    int
-	Username    string
-	Password    string
-	FromAddress string
-	FromName    string
-	BaseURL     string
-	GracePeriod time.Duration
+	SMTPHost         string
+	SMTPPort         int
+	Username         string
+	Password         string
+	FromAddress      string
+	FromName         string
+	BaseURL          string
+	GracePeriod      time.Duration
+	PasswordResetTTL time.Duration
 }
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Category is a question category. Questions refer to it by slug, which never changes once created.
+type Category struct {
+	ID            int       `json:"id"`
+	Slug          string    `json:"slug"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Position      int       `json:"position"`            // Categories are listed by position, then name
+	ParentID      *int      `json:"parent_id,omitempty"` // Set for sub-categories
+	QuestionCount int       `json:"question_count"`      // Approved questions directly in the category
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CategoryRequest for creating/updating categories. The slug is derived from the name when left
+// empty on creation and can not be changed afterwards.
+type CategoryRequest struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Position    *int   `json:"position,omitempty"` // Defaults to after the last category
+	ParentID    *int   `json:"parent_id,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// DuplicateCandidate is an existing question found similar to another one
+type DuplicateCandidate struct {
+	QuestionID int     `json:"question_id"`
+	Question   string  `json:"question"`
+	Category   string  `json:"category"`
+	Status     string  `json:"status"`
+	Score      float64 `json:"score"`
+}
+
+// DuplicateQuestionError is returned when a new question looks like questions already in the bank
+type DuplicateQuestionError struct {
+	Duplicates []DuplicateCandidate
+}
+
+func (e *DuplicateQuestionError) Error() string {
+	return "question looks like a duplicate of an existing question"
+}
+
+// ImportDuplicate reports an imported question skipped because it resembles existing ones
+type ImportDuplicate struct {
+	QuestionNumber int                  `json:"question_number"`
+	Row            int                  `json:"row,omitempty"` // Spreadsheet row for CSV imports
+	Question       string               `json:"question"`
+	Matches        []DuplicateCandidate `json:"matches"`
+}
+
+// DuplicateCluster is a group of questions linked by pairwise similarity above the threshold
+type DuplicateCluster struct {
+	Questions []DuplicateCandidate `json:"questions"` // Score is the best similarity to another member
+	MaxScore  float64              `json:"max_score"`
+}
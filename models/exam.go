@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// ExamSection asks for a number of questions, optionally restricted to a category and/or difficulty
+type ExamSection struct {
+	Category   string `json:"category,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Count      int    `json:"count"`
+}
+
+// ExamBlueprint describes how a mock exam paper is built and graded
+type ExamBlueprint struct {
+	ID               int           `json:"id"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	QuestionCount    int           `json:"question_count"`
+	TimeLimitMinutes int           `json:"time_limit_minutes"`
+	PassMark         float64       `json:"pass_mark"` // Fraction of correct answers required, e.g. 0.8
+	Sections         []ExamSection `json:"sections"`
+	IsDefault        bool          `json:"is_default"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+// ExamBlueprintRequest for creating/updating blueprints
+type ExamBlueprintRequest struct {
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	TimeLimitMinutes int           `json:"time_limit_minutes"`
+	PassMark         float64       `json:"pass_mark"`
+	Sections         []ExamSection `json:"sections"`
+	IsDefault        bool          `json:"is_default"`
+}
+
+// ExamAttempt is one sitting of a mock exam. The result fields stay empty until it is graded.
+type ExamAttempt struct {
+	ID               int                     `json:"id"`
+	UserID           int                     `json:"user_id"`
+	BlueprintID      *int                    `json:"blueprint_id,omitempty"`
+	BlueprintName    string                  `json:"blueprint_name"`
+	QuestionIDs      []int                   `json:"question_ids"`
+	PassMark         float64                 `json:"pass_mark"`
+	Status           string                  `json:"status"`
+	StartedAt        time.Time               `json:"started_at"`
+	DeadlineAt       time.Time               `json:"deadline_at"`
+	SubmittedAt      *time.Time              `json:"submitted_at,omitempty"`
+	RemainingSeconds int                     `json:"remaining_seconds"`
+	Answered         int                     `json:"answered"`
+	Total            int                     `json:"total"`
+	Correct          *int                    `json:"correct,omitempty"`
+	Score            *float64                `json:"score,omitempty"`
+	Passed           *bool                   `json:"passed,omitempty"`
+	Breakdown        map[string]CategoryStat `json:"breakdown,omitempty"`
+}
+
+// ExamAnswer is an answer saved during an exam
+type ExamAnswer struct {
+	QuestionID int    `json:"question_id"`
+	UserAnswer string `json:"user_answer"`
+}
+
+// ExamAnswersRequest saves one or more answers, an answer can be changed until the exam is submitted
+type ExamAnswersRequest struct {
+	Answers []ExamAnswer `json:"answers"`
+}
+
+// ExamReviewItem is the graded outcome of one exam question
+type ExamReviewItem struct {
+	QuestionID    int         `json:"question_id"`
+	Question      string      `json:"question"`
+	QuestionType  string      `json:"question_type"`
+	Category      string      `json:"category"`
+	Difficulty    string      `json:"difficulty"`
+	Answered      bool        `json:"answered"`
+	UserAnswer    string      `json:"user_answer,omitempty"`
+	IsCorrect     bool        `json:"is_correct"`
+	CorrectAnswer interface{} `json:"correct_answer"`
+	Explanation   string      `json:"explanation,omitempty"`
+	Sources       []string    `json:"sources,omitempty"`
+}
+
+// ExamResult is a graded attempt with its per-question review
+type ExamResult struct {
+	Attempt ExamAttempt      `json:"attempt"`
+	Items   []ExamReviewItem `json:"items"`
+}
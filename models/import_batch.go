@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ImportBatch groups the questions brought in by one import, so they can be traced back to their
+// source and rolled back together
+type ImportBatch struct {
+	ID                int        `json:"id"`
+	ImportedBy        int        `json:"imported_by"`
+	ImporterUsername  string     `json:"importer_username,omitempty"`
+	SourceFilename    string     `json:"source_filename,omitempty"`
+	Format            string     `json:"format"` // json, csv or tsv
+	Status            string     `json:"status"` // active or rolled_back
+	QuestionCount     int        `json:"question_count"`
+	PendingQuestions  int        `json:"pending_questions"`
+	ApprovedQuestions int        `json:"approved_questions"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RolledBackBy      *int       `json:"rolled_back_by,omitempty"`
+	RolledBackAt      *time.Time `json:"rolled_back_at,omitempty"`
+}
+
+// ImportBatchRollback reports what rolling an import batch back removed
+type ImportBatchRollback struct {
+	BatchID          int `json:"batch_id"`
+	DeletedQuestions int `json:"deleted_questions"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ImportJob is an asynchronous import of an uploaded file, processed in chunks by the job queue
+type ImportJob struct {
+	ID                  int        `json:"id"`
+	CreatedBy           int        `json:"created_by"`
+	Status              string     `json:"status"` // queued, running, completed or failed
+	Format              string     `json:"format"` // json, csv or tsv
+	Delimiter           string     `json:"-"`
+	ListSeparator       string     `json:"-"`
+	AllowNearDuplicates bool       `json:"allow_near_duplicates"`
+	SourceFilename      string     `json:"source_filename,omitempty"`
+	FilePath            string     `json:"-"`
+	FileSize            int64      `json:"file_size"`
+	BytesProcessed      int64      `json:"bytes_processed"`
+	Progress            float64    `json:"progress"` // Share of the file processed, from 0 to 1
+	ProcessedQuestions  int        `json:"processed_questions"`
+	ImportedQuestions   int        `json:"imported_questions"`
+	SkippedQuestions    int        `json:"skipped_questions"`
+	Errors              []string   `json:"errors"`
+	ErrorCount          int        `json:"error_count"` // Errors only holds the first ones
+	Failure             string     `json:"failure,omitempty"`
+	ImportBatchID       *int       `json:"import_batch_id,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	StartedAt           *time.Time `json:"started_at,omitempty"`
+	FinishedAt          *time.Time `json:"finished_at,omitempty"`
+}
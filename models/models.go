@@ -8,6 +8,11 @@ func (session *Session) CanManageUsers() bool {
 	return session.Role == "admin"
 }
 
+// CanSeeAnswerKey reports whether answers and keywords may be served to this session
+func (session *Session) CanSeeAnswerKey() bool {
+	return session.Role == "moderator" || session.Role == "admin"
+}
+
 func (session *Session) CanEditQuestion(question *Question) bool {
 	// Admins and moderators can edit any question
 	if session.Role == "admin" || session.Role == "moderator" {
@@ -16,3 +21,8 @@ func (session *Session) CanEditQuestion(question *Question) bool {
 	// Users can only edit their own pending questions
 	return session.UserID == question.CreatedBy && question.Status == "pending"
 }
+
+// CanRollbackImports reports whether this session may delete every question of an import batch
+func (session *Session) CanRollbackImports() bool {
+	return session.Role == "admin"
+}
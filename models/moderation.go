@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// ModerationQueueItem is a pending question along with who is currently reviewing it
+type ModerationQueueItem struct {
+	Question
+	AssignedTo         *int       `json:"assigned_to,omitempty"`
+	AssignedToUsername string     `json:"assigned_to_username,omitempty"`
+	AssignedAt         *time.Time `json:"assigned_at,omitempty"`
+	AgeHours           float64    `json:"age_hours"`
+}
+
+// ModerationQueueFilter narrows down the moderation queue
+type ModerationQueueFilter struct {
+	Category    string
+	CreatedBy   int     // 0 means any creator
+	Creator     string  // creator username, alternative to CreatedBy
+	MinAgeHours float64 // only questions waiting at least this long
+	MaxAgeHours float64 // only questions waiting at most this long, 0 means no limit
+	Assignment  string  // "", "unassigned", "mine" or "assigned"
+	Limit       int
+	Offset      int
+}
+
+// AssignRequest hands a pending question to a moderator
+type AssignRequest struct {
+	ModeratorID int `json:"moderator_id"`
+}
+
+// ModerationDecision is one entry of a bulk moderation request
+type ModerationDecision struct {
+	QuestionID int    `json:"question_id"`
+	Action     string `json:"action"` // "approve" or "reject"
+	Reason     string `json:"reason,omitempty"`
+}
+
+// BulkModerationRequest applies several decisions at once
+type BulkModerationRequest struct {
+	Decisions []ModerationDecision `json:"decisions"`
+}
+
+// ModerationDecisionResult is the outcome of one decision of a bulk request
+type ModerationDecisionResult struct {
+	QuestionID int    `json:"question_id"`
+	Action     string `json:"action"`
+	Success    bool   `json:"success"`
+	Status     string `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BulkModerationResult reports a bulk request. Decisions are all or nothing: when one fails,
+// Applied is false and none of them were saved.
+type BulkModerationResult struct {
+	Applied   bool                       `json:"applied"`
+	Total     int                        `json:"total"`
+	Succeeded int                        `json:"succeeded"`
+	Failed    int                        `json:"failed"`
+	Results   []ModerationDecisionResult `json:"results"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// PracticeSession is a batch of questions frozen when the learner starts practising
+type PracticeSession struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	QuestionIDs []int      `json:"question_ids"`
+	ReviewMode  string     `json:"review_mode"`
+	Status      string     `json:"status"`
+	Answered    int        `json:"answered"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// PracticeReviewItem is the outcome of one question once the session is finished
+type PracticeReviewItem struct {
+	QuestionID    int         `json:"question_id"`
+	Question      string      `json:"question"`
+	QuestionType  string      `json:"question_type"`
+	Category      string      `json:"category"`
+	Answered      bool        `json:"answered"`
+	UserAnswer    string      `json:"user_answer,omitempty"`
+	IsCorrect     bool        `json:"is_correct"`
+	Score         float64     `json:"score"`
+	CorrectAnswer interface{} `json:"correct_answer"`
+	Explanation   string      `json:"explanation,omitempty"`
+	Sources       []string    `json:"sources,omitempty"`
+}
+
+// PracticeSessionReview is returned when a practice session is finished
+type PracticeSessionReview struct {
+	Session         PracticeSession      `json:"session"`
+	Items           []PracticeReviewItem `json:"items"`
+	Total           int                  `json:"total"`
+	Answered        int                  `json:"answered"`
+	Correct         int                  `json:"correct"`
+	Score           float64              `json:"score"`
+	DurationSeconds int                  `json:"duration_seconds"`
+}
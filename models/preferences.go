@@ -8,6 +8,7 @@ type UserPreferences struct {
 	PracticeSessionLength   int       `json:"practice_session_length"`
 	DifficultyPreference    string    `json:"difficulty_preference"`
 	CategoryPreference      []string  `json:"category_preference,omitempty"` // JSON array or NULL
+	TagPreference           []string  `json:"tag_preference,omitempty"`      // Questions carrying any of these tags, NULL for all
 	ReviewMode              string    `json:"review_mode"`
 	AutoAdvanceTimingOpen   int       `json:"auto_advance_timing_open"`   // milliseconds
 	AutoAdvanceTimingChoice int       `json:"auto_advance_timing_choice"` // milliseconds
@@ -17,6 +18,7 @@ type UserPreferences struct {
 	ThemeMode               string    `json:"theme_mode"`
 	StatsVisibility         bool      `json:"stats_visibility"`
 	InterfaceLanguage       string    `json:"interface_language"`
+	SelectionMode           string    `json:"selection_mode"` // "smart" or "spaced_repetition"
 	UpdatedAt               time.Time `json:"updated_at"`
 }
 
@@ -25,6 +27,7 @@ type UserPreferencesRequest struct {
 	PracticeSessionLength   *int      `json:"practice_session_length,omitempty"`
 	DifficultyPreference    *string   `json:"difficulty_preference,omitempty"`
 	CategoryPreference      *[]string `json:"category_preference,omitempty"`
+	TagPreference           *[]string `json:"tag_preference,omitempty"`
 	ReviewMode              *string   `json:"review_mode,omitempty"`
 	AutoAdvanceTimingOpen   *int      `json:"auto_advance_timing_open,omitempty"`
 	AutoAdvanceTimingChoice *int      `json:"auto_advance_timing_choice,omitempty"`
@@ -34,6 +37,7 @@ type UserPreferencesRequest struct {
 	ThemeMode               *string   `json:"theme_mode,omitempty"`
 	StatsVisibility         *bool     `json:"stats_visibility,omitempty"`
 	InterfaceLanguage       *string   `json:"interface_language,omitempty"`
+	SelectionMode           *string   `json:"selection_mode,omitempty"`
 }
 
 // GetDefaultPreferences returns default user preferences
@@ -43,6 +47,7 @@ func GetDefaultPreferences(userID int) *UserPreferences {
 		PracticeSessionLength:   10,
 		DifficultyPreference:    "adaptive",
 		CategoryPreference:      nil, // All categories
+		TagPreference:           nil, // Any tag or none
 		ReviewMode:              "immediate",
 		AutoAdvanceTimingOpen:   60000, // 60 seconds for open questions
 		AutoAdvanceTimingChoice: 30000, // 30 seconds for choice questions
@@ -52,6 +57,7 @@ func GetDefaultPreferences(userID int) *UserPreferences {
 		ThemeMode:               "system",
 		StatsVisibility:         true,
 		InterfaceLanguage:       "fr",
+		SelectionMode:           "smart",
 		UpdatedAt:               time.Now(),
 	}
 }
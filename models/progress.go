@@ -4,13 +4,35 @@ import "time"
 
 // Progress represents user progress on questions
 type Progress struct {
-	ID               int       `json:"id"`
-	UserID           int       `json:"user_id"`
-	QuestionID       int       `json:"question_id"`
-	UserAnswer       string    `json:"user_answer"`
-	IsCorrect        bool      `json:"is_correct"`
-	AnsweredAt       time.Time `json:"answered_at"`
-	TimeTakenSeconds int       `json:"time_taken_seconds"`
+	ID                int       `json:"id"`
+	UserID            int       `json:"user_id"`
+	QuestionID        int       `json:"question_id"`
+	UserAnswer        string    `json:"user_answer"`
+	IsCorrect         bool      `json:"is_correct"`
+	Score             float64   `json:"score"` // 0..1, partial credit for multiple_select
+	AnsweredAt        time.Time `json:"answered_at"`
+	TimeTakenSeconds  int       `json:"time_taken_seconds"`
+	PracticeSessionID *int      `json:"practice_session_id,omitempty"`
+}
+
+// ProgressResult is returned once an answer is recorded, it is where learners get to see the answer key
+// along with why it is the answer
+type ProgressResult struct {
+	Progress
+	CorrectAnswer interface{} `json:"correct_answer"`
+	Explanation   string      `json:"explanation,omitempty"`
+	Sources       []string    `json:"sources,omitempty"`
+	Confidence    float64     `json:"confidence"`
+	Reason        string      `json:"reason"`
+}
+
+// AnswerEvaluation is the verdict on an answer. Confidence is how sure the matcher is of the
+// verdict (1 = certain), Reason says what decided it, e.g. "exact_match" or "typo_match".
+type AnswerEvaluation struct {
+	IsCorrect  bool    `json:"is_correct"`
+	Score      float64 `json:"score"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
 }
 
 // ProgressRequest for recording progress
@@ -18,6 +40,9 @@ type ProgressRequest struct {
 	QuestionID       int    `json:"question_id"`
 	UserAnswer       string `json:"user_answer"`
 	TimeTakenSeconds int    `json:"time_taken_seconds"`
+
+	// Set by the practice session endpoints, never read from the request body
+	PracticeSessionID *int `json:"-"`
 }
 
 // Stats represents user statistics
@@ -26,12 +51,26 @@ type Stats struct {
 	Answered       int                     `json:"answered"`
 	Correct        int                     `json:"correct"`
 	Accuracy       float64                 `json:"accuracy"`
+	WeightedScore  float64                 `json:"weighted_score"` // Average score, with partial credit
 	Streak         int                     `json:"streak"`
 	Categories     map[string]CategoryStat `json:"categories"`
 }
 
 // CategoryStat represents stats for a specific category
 type CategoryStat struct {
-	Answered int `json:"answered"`
-	Correct  int `json:"correct"`
+	Answered      int     `json:"answered"`
+	Correct       int     `json:"correct"`
+	WeightedScore float64 `json:"weighted_score"` // Average score, with partial credit
+}
+
+// QuestionSchedule is the spaced repetition state of a question for one user
+type QuestionSchedule struct {
+	UserID         int       `json:"user_id"`
+	QuestionID     int       `json:"question_id"`
+	Repetitions    int       `json:"repetitions"`
+	IntervalDays   int       `json:"interval_days"`
+	EaseFactor     float64   `json:"ease_factor"`
+	Lapses         int       `json:"lapses"`
+	DueAt          time.Time `json:"due_at"`
+	LastReviewedAt time.Time `json:"last_reviewed_at"`
 }
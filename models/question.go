@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Question represents a question in the system
 type Question struct {
@@ -11,6 +17,9 @@ type Question struct {
 	Choices         []string   `json:"choices,omitempty"`
 	Answer          string     `json:"answer"`
 	Keywords        []string   `json:"keywords"`
+	Tags            []string   `json:"tags,omitempty"`
+	Explanation     string     `json:"explanation,omitempty"` // Markdown, shown once the question is answered
+	Sources         []string   `json:"sources,omitempty"`     // URLs or citations backing the answer
 	Difficulty      string     `json:"difficulty"`
 	CreatedBy       int        `json:"created_by"`
 	Status          string     `json:"status"`
@@ -21,6 +30,74 @@ type Question struct {
 	CreatorUsername string     `json:"creator_username,omitempty"`
 }
 
+// LearnerQuestion is the view of a question served to learners, it never carries the answer key
+type LearnerQuestion struct {
+	ID              int       `json:"id"`
+	Category        string    `json:"category"`
+	Question        string    `json:"question"`
+	QuestionType    string    `json:"question_type"`
+	Choices         []string  `json:"choices,omitempty"`
+	Prompts         []string  `json:"prompts,omitempty"` // Left-hand side of a matching question
+	Tags            []string  `json:"tags,omitempty"`
+	Difficulty      string    `json:"difficulty"`
+	CreatedBy       int       `json:"created_by"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CreatorUsername string    `json:"creator_username,omitempty"`
+}
+
+// LearnerView strips the answer, the keywords (which hint at it) and the explanation and sources
+// (which give it away). Ordering and matching items are shuffled since they are stored in answer order.
+func (q *Question) LearnerView() LearnerQuestion {
+	choices := q.Choices
+	var prompts []string
+
+	switch q.QuestionType {
+	case "ordering", "matching":
+		choices = append([]string(nil), q.Choices...)
+		rand.Shuffle(len(choices), func(i, j int) { choices[i], choices[j] = choices[j], choices[i] })
+	}
+
+	if q.QuestionType == "matching" {
+		var pairs map[string]string
+		if err := json.Unmarshal([]byte(q.Answer), &pairs); err == nil {
+			for prompt := range pairs {
+				prompts = append(prompts, prompt)
+			}
+			sort.Strings(prompts)
+		}
+	}
+
+	return LearnerQuestion{
+		ID:              q.ID,
+		Category:        q.Category,
+		Question:        q.Question,
+		QuestionType:    q.QuestionType,
+		Choices:         choices,
+		Prompts:         prompts,
+		Tags:            q.Tags,
+		Difficulty:      q.Difficulty,
+		CreatedBy:       q.CreatedBy,
+		Status:          q.Status,
+		CreatedAt:       q.CreatedAt,
+		UpdatedAt:       q.UpdatedAt,
+		CreatorUsername: q.CreatorUsername,
+	}
+}
+
+// DisplayAnswer returns the answer key in its structured form, e.g. a list for multiple_select
+func (q *Question) DisplayAnswer() interface{} {
+	answer := strings.TrimSpace(q.Answer)
+	if strings.HasPrefix(answer, "[") || strings.HasPrefix(answer, "{") {
+		var structured interface{}
+		if err := json.Unmarshal([]byte(answer), &structured); err == nil {
+			return structured
+		}
+	}
+	return q.Answer
+}
+
 // QuestionRequest for creating/updating questions
 type QuestionRequest struct {
 	Category     string   `json:"category"`
@@ -29,6 +106,9 @@ type QuestionRequest struct {
 	Choices      []string `json:"choices,omitempty"`
 	Answer       string   `json:"answer"`
 	Keywords     []string `json:"keywords"`
+	Tags         []string `json:"tags,omitempty"`        // Replaces the tags of an updated question, left out keeps them
+	Explanation  *string  `json:"explanation,omitempty"` // Left out keeps the explanation of an updated question
+	Sources      []string `json:"sources,omitempty"`     // Replaces the sources of an updated question, left out keeps them
 	Difficulty   string   `json:"difficulty"`
 	Status       string   `json:"status,omitempty"`
 }
@@ -42,7 +122,13 @@ type ApprovalRequest struct {
 // Import types
 
 type ImportRequest struct {
-	Questions []QuestionImport `json:"questions"`
+	Questions           []QuestionImport `json:"questions"`
+	AllowNearDuplicates bool             `json:"allow_near_duplicates,omitempty"` // exact duplicates are always skipped
+	DryRun              bool             `json:"dry_run,omitempty"`               // validate and report without storing anything
+	Offset              int              `json:"-"`                               // questions before this one in the file, for chunked imports
+	Format              string           `json:"-"`                               // json, csv or tsv, recorded on the import batch
+	SourceFilename      string           `json:"-"`                               // name of the imported file, recorded on every question
+	BatchID             int              `json:"-"`                               // batch the questions join, 0 opens a new one
 }
 
 type QuestionImport struct {
@@ -52,13 +138,85 @@ type QuestionImport struct {
 	Choices      []string    `json:"choices,omitempty"`
 	Answer       interface{} `json:"answer"`
 	Keywords     []string    `json:"keywords"`
+	Tags         []string    `json:"tags,omitempty"`
+	Explanation  string      `json:"explanation,omitempty"`
+	Sources      []string    `json:"sources,omitempty"`
 	Difficulty   string      `json:"difficulty"`
+	Row          int         `json:"-"` // Spreadsheet row the question was read from, 0 for JSON imports
 }
 
 type ImportResult struct {
-	TotalQuestions    int      `json:"total_questions"`
-	ImportedQuestions int      `json:"imported_questions"`
-	SkippedQuestions  int      `json:"skipped_questions"`
-	Errors            []string `json:"errors"`
-	TimeTaken         string   `json:"time_taken"`
+	TotalQuestions    int                `json:"total_questions"`
+	ImportedQuestions int                `json:"imported_questions"`
+	SkippedQuestions  int                `json:"skipped_questions"`
+	Errors            []string           `json:"errors"`
+	NearDuplicates    []ImportDuplicate  `json:"near_duplicates,omitempty"`
+	DryRun            bool               `json:"dry_run,omitempty"` // counts are what the import would have done
+	Report            []ImportReportItem `json:"report,omitempty"`
+	BatchID           int                `json:"batch_id,omitempty"` // import batch the questions were recorded under
+	TimeTaken         string             `json:"time_taken"`
+}
+
+// ImportReportItem is the dry run verdict on one imported question: imported, skipped as a duplicate
+// or rejected as invalid, with the normalized values that would be stored
+type ImportReportItem struct {
+	QuestionNumber int              `json:"question_number"`
+	Row            int              `json:"row,omitempty"`
+	Outcome        string           `json:"outcome"`
+	Error          string           `json:"error,omitempty"`
+	Stored         *QuestionRequest `json:"stored,omitempty"`
+}
+
+// QuestionListFilter selects, orders and paginates GET /questions
+type QuestionListFilter struct {
+	Category      string
+	Difficulty    string
+	QuestionType  string
+	Status        string
+	CreatedBy     int      // 0 means any creator
+	Tags          []string // questions carrying every one of these tags
+	ImportBatchID int      // 0 means any origin, imported or not
+	Search        string   // substring of the question text
+	Sort          string   // created_at, updated_at, id, category or difficulty
+	Order         string   // asc or desc
+	Limit         int
+	Cursor        string // opaque, taken from the next_cursor of the previous page
+}
+
+// QuestionPage is one page of questions along with the number of questions matching the filter
+type QuestionPage struct {
+	Questions  []Question
+	Total      int
+	NextCursor string
+}
+
+// QuestionSearchHit is a full-text search match. Highlights holds the matched fields with the
+// matching terms wrapped in <mark> tags.
+type QuestionSearchHit struct {
+	Question   Question
+	Rank       float64
+	Highlights map[string]string
+}
+
+// QuestionExportFilter selects the questions written by GET /export, empty fields match everything
+type QuestionExportFilter struct {
+	Category   string
+	Difficulty string
+	Status     string
+}
+
+// ImportForm returns the question as POST /import expects it, so an export can be imported back
+func (q *Question) ImportForm() QuestionImport {
+	return QuestionImport{
+		Category:     q.Category,
+		Question:     q.Question,
+		QuestionType: q.QuestionType,
+		Choices:      q.Choices,
+		Answer:       q.DisplayAnswer(),
+		Keywords:     q.Keywords,
+		Tags:         q.Tags,
+		Explanation:  q.Explanation,
+		Sources:      q.Sources,
+		Difficulty:   q.Difficulty,
+	}
 }
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// QuestionReview is a moderation decision taken on a pending question
+type QuestionReview struct {
+	ID               int       `json:"id"`
+	QuestionID       int       `json:"question_id"`
+	ReviewerID       int       `json:"reviewer_id"`
+	ReviewerUsername string    `json:"reviewer_username,omitempty"`
+	Decision         string    `json:"decision"` // "approve" or "reject"
+	Reason           string    `json:"reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// QuestionComment is a message in the thread between a question's author and the moderators
+type QuestionComment struct {
+	ID         int       `json:"id"`
+	QuestionID int       `json:"question_id"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username,omitempty"`
+	UserRole   string    `json:"user_role,omitempty"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CommentRequest posts a comment on a question
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// ResubmitRequest sends a rejected question back to the moderation queue, optionally with a comment
+type ResubmitRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"reflect"
+	"time"
+)
+
+// QuestionRevision is a snapshot of a question taken every time it is created, edited, reviewed or rolled back
+type QuestionRevision struct {
+	ID                int       `json:"id"`
+	QuestionID        int       `json:"question_id"`
+	RevisionNumber    int       `json:"revision_number"`
+	Category          string    `json:"category"`
+	Question          string    `json:"question"`
+	QuestionType      string    `json:"question_type"`
+	Choices           []string  `json:"choices,omitempty"`
+	Answer            string    `json:"answer"`
+	Keywords          []string  `json:"keywords"`
+	Explanation       string    `json:"explanation,omitempty"`
+	Sources           []string  `json:"sources,omitempty"`
+	Tags              []string  `json:"tags,omitempty"`
+	Difficulty        string    `json:"difficulty"`
+	Status            string    `json:"status"`
+	ChangeType        string    `json:"change_type"` // baseline, create, import, update, approve, reject, resubmit or rollback
+	ChangedBy         *int      `json:"changed_by,omitempty"`
+	ChangedByUsername string    `json:"changed_by_username,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// RevisionFieldChange is one field that differs between two revisions
+type RevisionFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// QuestionRevisionDiff lists the fields changed from one revision to another
+type QuestionRevisionDiff struct {
+	QuestionID   int                   `json:"question_id"`
+	FromRevision int                   `json:"from_revision"`
+	ToRevision   int                   `json:"to_revision"`
+	Changes      []RevisionFieldChange `json:"changes"`
+}
+
+// Diff compares the question content of two revisions field by field
+func (r *QuestionRevision) Diff(other *QuestionRevision) QuestionRevisionDiff {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"category", r.Category, other.Category},
+		{"question", r.Question, other.Question},
+		{"question_type", r.QuestionType, other.QuestionType},
+		{"choices", nonNilStrings(r.Choices), nonNilStrings(other.Choices)},
+		{"answer", r.Answer, other.Answer},
+		{"keywords", nonNilStrings(r.Keywords), nonNilStrings(other.Keywords)},
+		{"explanation", r.Explanation, other.Explanation},
+		{"sources", nonNilStrings(r.Sources), nonNilStrings(other.Sources)},
+		{"tags", nonNilStrings(r.Tags), nonNilStrings(other.Tags)},
+		{"difficulty", r.Difficulty, other.Difficulty},
+		{"status", r.Status, other.Status},
+	}
+
+	diff := QuestionRevisionDiff{
+		QuestionID:   r.QuestionID,
+		FromRevision: r.RevisionNumber,
+		ToRevision:   other.RevisionNumber,
+		Changes:      []RevisionFieldChange{},
+	}
+
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.from, f.to) {
+			diff.Changes = append(diff.Changes, RevisionFieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+
+	return diff
+}
+
+// nonNilStrings treats a missing list and an empty list as the same value
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
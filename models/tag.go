@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Tag groups questions across categories, e.g. "revolution" or "frequently asked at interview".
+// Questions refer to it by slug, which never changes once created.
+type Tag struct {
+	ID            int       `json:"id"`
+	Slug          string    `json:"slug"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	QuestionCount int       `json:"question_count"` // Approved questions carrying the tag
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TagRequest for creating/updating tags. The slug is derived from the name when left empty on
+// creation and can not be changed afterwards.
+type TagRequest struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
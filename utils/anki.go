@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/models"
+)
+
+// AnkiDeckHeader is the header of an Anki text import file: tab separated Front/Back/Tags notes
+// whose fields are HTML, going to the given deck with the Basic note type
+func AnkiDeckHeader(deck string) string {
+	return "#separator:tab\n" +
+		"#html:true\n" +
+		"#notetype:Basic\n" +
+		"#deck:" + ankiField(deck) + "\n" +
+		"#columns:Front\tBack\tTags\n" +
+		"#tags column:3\n"
+}
+
+// AnkiNote renders a question as one line of an Anki text import file
+func AnkiNote(q *models.Question) string {
+	tags := []string{ankiTag(q.Category), "difficulty::" + q.Difficulty, "type::" + q.QuestionType}
+	for _, tag := range q.Tags {
+		tags = append(tags, "tag::"+strings.Join(strings.Fields(tag), "_"))
+	}
+	back := ankiBack(q) + ankiExplanation(q)
+	return ankiField(ankiFront(q)) + "\t" + ankiField(back) + "\t" + strings.Join(tags, " ") + "\n"
+}
+
+// ankiExplanation follows the answer with the explanation and sources of the question, if any
+func ankiExplanation(q *models.Question) string {
+	if q.Explanation == "" && len(q.Sources) == 0 {
+		return ""
+	}
+	extra := "<hr>"
+	if q.Explanation != "" {
+		extra += html.EscapeString(q.Explanation)
+	}
+	return extra + ankiList("ul", q.Sources)
+}
+
+func ankiFront(q *models.Question) string {
+	front := html.EscapeString(q.Question)
+
+	switch q.QuestionType {
+	case "multiple_choice", "multiple_select", "true_false", "ordering":
+		front += ankiList("ul", q.Choices)
+	case "matching":
+		// The prompts are the keys of the answer key, the choices what they are matched with
+		var pairs map[string]string
+		if err := json.Unmarshal([]byte(q.Answer), &pairs); err == nil {
+			prompts := make([]string, 0, len(pairs))
+			for prompt := range pairs {
+				prompts = append(prompts, prompt)
+			}
+			sort.Strings(prompts)
+			front += ankiList("ol", prompts)
+		}
+		front += ankiList("ul", q.Choices)
+	}
+
+	return front
+}
+
+func ankiBack(q *models.Question) string {
+	switch answer := q.DisplayAnswer().(type) {
+	case []interface{}:
+		items := make([]string, 0, len(answer))
+		for _, item := range answer {
+			items = append(items, fmt.Sprint(item))
+		}
+		if q.QuestionType == "ordering" {
+			return ankiList("ol", items)
+		}
+		return ankiList("ul", items)
+	case map[string]interface{}:
+		if q.QuestionType == "numeric" {
+			if key, err := ParseNumericAnswer(q.Answer); err == nil {
+				if key.Tolerance > 0 {
+					return fmt.Sprintf("%g (± %g)", *key.Value, key.Tolerance)
+				}
+				return fmt.Sprintf("%g", *key.Value)
+			}
+		}
+		pairs := make([]string, 0, len(answer))
+		for prompt, match := range answer {
+			pairs = append(pairs, fmt.Sprintf("%s → %v", prompt, match))
+		}
+		sort.Strings(pairs)
+		return ankiList("ul", pairs)
+	default:
+		return html.EscapeString(q.Answer)
+	}
+}
+
+func ankiList(tag string, items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<" + tag + ">")
+	for _, item := range items {
+		b.WriteString("<li>" + html.EscapeString(item) + "</li>")
+	}
+	b.WriteString("</" + tag + ">")
+	return b.String()
+}
+
+// ankiField keeps a field on its line: tabs would start a new field and newlines a new note
+func ankiField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r\n", "<br>", "\n", "<br>", "\r", "<br>").Replace(s)
+}
+
+// ankiTag turns a category into a tag, Anki tags can not contain spaces
+func ankiTag(category string) string {
+	return "category::" + strings.Join(strings.Fields(category), "_")
+}
@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/adamspd/QuizzApi/models"
+)
+
+// AnswerMatcherConfig controls how forgiving open_text answer checking is
+type AnswerMatcherConfig struct {
+	FoldAccents      bool // "Republique" matches "République"
+	StripPunctuation bool // "marianne." matches "Marianne"
+	StripArticles    bool // "Marseillaise" matches "La Marseillaise"
+	UseKeywords      bool // An answer containing all the question's keywords is accepted
+	MaxTypos         int  // Upper bound of the edit distance tolerated on long answers
+	CharsPerTypo     int  // One typo is tolerated per this many characters of the expected answer
+}
+
+// Reasons reported with an answer verdict
+const (
+	ReasonExactMatch      = "exact_match"
+	ReasonNormalizedMatch = "normalized_match"
+	ReasonTypoMatch       = "typo_match"
+	ReasonKeywordMatch    = "keyword_match"
+	ReasonMissingKeywords = "missing_keywords"
+	ReasonPartialMatch    = "partial_match"
+	ReasonWithinTolerance = "within_tolerance"
+	ReasonInvalidFormat   = "invalid_format"
+	ReasonNoMatch         = "no_match"
+	ReasonUnknownType     = "unknown_question_type"
+)
+
+// keywordMatchConfidence is reported when the verdict only rests on the keywords
+const keywordMatchConfidence = 0.8
+
+var answerMatcherConfig = DefaultAnswerMatcherConfig()
+
+// DefaultAnswerMatcherConfig enables every normalization and tolerates up to 2 typos
+func DefaultAnswerMatcherConfig() AnswerMatcherConfig {
+	return AnswerMatcherConfig{
+		FoldAccents:      true,
+		StripPunctuation: true,
+		StripArticles:    true,
+		UseKeywords:      true,
+		MaxTypos:         2,
+		CharsPerTypo:     5,
+	}
+}
+
+// LoadAnswerMatcherConfig reads the ANSWER_MATCH_* environment variables
+func LoadAnswerMatcherConfig() AnswerMatcherConfig {
+	defaults := DefaultAnswerMatcherConfig()
+	return AnswerMatcherConfig{
+		FoldAccents:      getEnvBool("ANSWER_MATCH_FOLD_ACCENTS", defaults.FoldAccents),
+		StripPunctuation: getEnvBool("ANSWER_MATCH_STRIP_PUNCTUATION", defaults.StripPunctuation),
+		StripArticles:    getEnvBool("ANSWER_MATCH_STRIP_ARTICLES", defaults.StripArticles),
+		UseKeywords:      getEnvBool("ANSWER_MATCH_USE_KEYWORDS", defaults.UseKeywords),
+		MaxTypos:         GetEnvInt("ANSWER_MATCH_MAX_TYPOS", defaults.MaxTypos),
+		CharsPerTypo:     GetEnvInt("ANSWER_MATCH_CHARS_PER_TYPO", defaults.CharsPerTypo),
+	}
+}
+
+// SetAnswerMatcherConfig replaces the configuration used by EvaluateAnswer
+func SetAnswerMatcherConfig(config AnswerMatcherConfig) {
+	answerMatcherConfig = config
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+var accentFolding = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a",
+	"ç", "c",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "í", "i",
+	"ô", "o", "ö", "o", "ó", "o",
+	"ù", "u", "û", "u", "ü", "u", "ú", "u",
+	"ÿ", "y",
+	"œ", "oe", "æ", "ae",
+)
+
+var frenchArticles = map[string]bool{"le": true, "la": true, "les": true, "l": true}
+
+// NormalizeOpenAnswer applies the configured normalizations to a free text answer
+func NormalizeOpenAnswer(answer string, config AnswerMatcherConfig) string {
+	normalized := strings.ToLower(strings.TrimSpace(answer))
+	normalized = strings.ReplaceAll(normalized, "’", "'")
+
+	if config.FoldAccents {
+		normalized = accentFolding.Replace(normalized)
+	}
+
+	if !config.StripPunctuation && !config.StripArticles {
+		return normalized
+	}
+
+	// Apostrophes always split words so "l'hymne" gives the article "l" and "hymne"
+	tokens := strings.FieldsFunc(normalized, func(r rune) bool {
+		if r == '\'' {
+			return true
+		}
+		if config.StripPunctuation {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}
+		return unicode.IsSpace(r)
+	})
+
+	if config.StripArticles {
+		var kept []string
+		for _, token := range tokens {
+			if !frenchArticles[token] {
+				kept = append(kept, token)
+			}
+		}
+		// An answer made only of articles is kept as is
+		if len(kept) > 0 {
+			tokens = kept
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// EvaluateAnswer checks an answer and explains the verdict. Confidence is how sure the
+// matcher is of the verdict, 1 being certain.
+func EvaluateAnswer(question *models.Question, userAnswer string) models.AnswerEvaluation {
+	switch question.QuestionType {
+	case "open_text":
+		return evaluateOpenTextAnswer(question, userAnswer, answerMatcherConfig)
+
+	case "multiple_choice", "true_false":
+		return exactEvaluation(NormalizeAnswer(userAnswer) == NormalizeAnswer(question.Answer))
+
+	case "multiple_select":
+		isCorrect, score := scoreMultipleSelectAnswer(question.Answer, userAnswer)
+		evaluation := exactEvaluation(isCorrect)
+		evaluation.Score = score
+		if !isCorrect && score > 0 {
+			evaluation.Reason = ReasonPartialMatch
+		}
+		return evaluation
+
+	case "numeric":
+		return evaluateNumericAnswer(question, userAnswer)
+
+	case "ordering":
+		return evaluateOrderingAnswer(question, userAnswer)
+
+	case "matching":
+		return evaluateMatchingAnswer(question, userAnswer)
+
+	case "cloze":
+		return evaluateClozeAnswer(question, userAnswer)
+
+	default:
+		LogError("Unknown question type: %s", question.QuestionType)
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonUnknownType}
+	}
+}
+
+func exactEvaluation(isCorrect bool) models.AnswerEvaluation {
+	if isCorrect {
+		return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: 1, Reason: ReasonExactMatch}
+	}
+	return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonNoMatch}
+}
+
+func evaluateOpenTextAnswer(question *models.Question, userAnswer string, config AnswerMatcherConfig) models.AnswerEvaluation {
+	if NormalizeAnswer(userAnswer) == NormalizeAnswer(question.Answer) {
+		return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: 1, Reason: ReasonExactMatch}
+	}
+
+	given := NormalizeOpenAnswer(userAnswer, config)
+	expected := NormalizeOpenAnswer(question.Answer, config)
+
+	if given == expected {
+		return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: 1, Reason: ReasonNormalizedMatch}
+	}
+
+	distance := levenshtein(given, expected)
+	expectedLength := len([]rune(expected))
+
+	if distance <= allowedTypos(expected, config) {
+		return models.AnswerEvaluation{
+			IsCorrect:  true,
+			Score:      1,
+			Confidence: roundConfidence(1 - float64(distance)/float64(expectedLength)),
+			Reason:     ReasonTypoMatch,
+		}
+	}
+
+	if config.UseKeywords && len(question.Keywords) > 0 {
+		found := 0
+		for _, keyword := range question.Keywords {
+			if containsKeyword(given, NormalizeOpenAnswer(keyword, config), config) {
+				found++
+			}
+		}
+
+		if found == len(question.Keywords) {
+			return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: keywordMatchConfidence, Reason: ReasonKeywordMatch}
+		}
+		if found > 0 {
+			return models.AnswerEvaluation{
+				IsCorrect:  false,
+				Confidence: roundConfidence(1 - float64(found)/float64(len(question.Keywords))*keywordMatchConfidence),
+				Reason:     ReasonMissingKeywords,
+			}
+		}
+	}
+
+	// The closer a text answer was, the less certain the rejection; numbers are simply wrong
+	confidence := 1.0
+	longest := expectedLength
+	if n := len([]rune(given)); n > longest {
+		longest = n
+	}
+	if longest > 0 && strings.IndexFunc(expected, unicode.IsDigit) < 0 {
+		confidence = float64(distance) / float64(longest)
+	}
+
+	return models.AnswerEvaluation{IsCorrect: false, Confidence: roundConfidence(confidence), Reason: ReasonNoMatch}
+}
+
+// allowedTypos scales with the answer length; numbers and dates must be exact
+func allowedTypos(expected string, config AnswerMatcherConfig) int {
+	if config.CharsPerTypo <= 0 || strings.IndexFunc(expected, unicode.IsDigit) >= 0 {
+		return 0
+	}
+
+	allowed := len([]rune(expected)) / config.CharsPerTypo
+	if allowed > config.MaxTypos {
+		allowed = config.MaxTypos
+	}
+	return allowed
+}
+
+// containsKeyword looks for the keyword as whole words, each word tolerating typos like a full answer
+func containsKeyword(answer, keyword string, config AnswerMatcherConfig) bool {
+	if keyword == "" {
+		return false
+	}
+
+	answerWords := strings.Fields(answer)
+	keywordWords := strings.Fields(keyword)
+
+	for start := 0; start+len(keywordWords) <= len(answerWords); start++ {
+		matched := true
+		for i, word := range keywordWords {
+			if levenshtein(answerWords[start+i], word) > allowedTypos(word, config) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// levenshtein returns the edit distance between two strings, counted in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func roundConfidence(confidence float64) float64 {
+	if confidence < 0 {
+		confidence = 0
+	}
+	if confidence > 1 {
+		confidence = 1
+	}
+	return float64(int(confidence*100+0.5)) / 100
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCategorySlugLength bounds category slugs, they are repeated on every question
+const maxCategorySlugLength = 64
+
+// CategorySlug derives a slug from a display name: lowercased, words joined by hyphens, anything
+// but letters and digits dropped. Accents are kept, existing categories like "laïcité" use them.
+func CategorySlug(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			pendingHyphen = true
+		}
+	}
+	return b.String()
+}
+
+// IsValidCategorySlug tells whether a slug chosen for a new category is lowercase letters, digits,
+// hyphens and underscores
+func IsValidCategorySlug(slug string) bool {
+	if slug == "" || utf8.RuneCountInString(slug) > maxCategorySlugLength {
+		return false
+	}
+	for _, r := range slug {
+		if !(unicode.IsLower(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// CategoryDisplayName turns a stored category value into a display name by capitalizing it
+func CategoryDisplayName(slug string) string {
+	r, size := utf8.DecodeRuneInString(slug)
+	if r == utf8.RuneError {
+		return slug
+	}
+	return string(unicode.ToUpper(r)) + slug[size:]
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"time"
@@ -45,3 +46,9 @@ func GenerateVerificationToken() string {
 	}
 	return hex.EncodeToString(bytes)
 }
+
+// HashToken returns the SHA-256 digest of a token so it never has to be stored in plain text
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
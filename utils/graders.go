@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/models"
+)
+
+// Graders of the structured question types. Each returns a verdict with a 0..1 score, the
+// answer only counts as correct when the score is full.
+
+func evaluateNumericAnswer(question *models.Question, userAnswer string) models.AnswerEvaluation {
+	given, err := ParseNumber(userAnswer)
+	if err != nil {
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	expected, tolerance, err := numericAnswerKey(question.Answer)
+	if err != nil {
+		LogError("Invalid numeric answer key for question %d: %v", question.ID, err)
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	difference := math.Abs(given - expected)
+	switch {
+	case difference == 0:
+		return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: 1, Reason: ReasonExactMatch}
+	case difference <= tolerance:
+		return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: 1, Reason: ReasonWithinTolerance}
+	default:
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonNoMatch}
+	}
+}
+
+func numericAnswerKey(answer string) (float64, float64, error) {
+	answer = strings.TrimSpace(answer)
+	if strings.HasPrefix(answer, "{") {
+		key, err := ParseNumericAnswer(answer)
+		if err != nil {
+			return 0, 0, err
+		}
+		return *key.Value, key.Tolerance, nil
+	}
+
+	value, err := ParseNumber(answer)
+	return value, 0, err
+}
+
+// evaluateOrderingAnswer gives credit for each item in its right position
+func evaluateOrderingAnswer(question *models.Question, userAnswer string) models.AnswerEvaluation {
+	var expected []string
+	if err := json.Unmarshal([]byte(question.Answer), &expected); err != nil || len(expected) == 0 {
+		LogError("Invalid ordering answer key for question %d: %v", question.ID, err)
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	given, err := parseAnswerList(strings.TrimSpace(userAnswer))
+	if err != nil {
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	inPlace := 0
+	for i := range expected {
+		if i < len(given) && NormalizeAnswer(given[i]) == NormalizeAnswer(expected[i]) {
+			inPlace++
+		}
+	}
+
+	return partialEvaluation(inPlace, len(expected), len(given) == len(expected))
+}
+
+// evaluateMatchingAnswer gives credit for each prompt paired with its right match
+func evaluateMatchingAnswer(question *models.Question, userAnswer string) models.AnswerEvaluation {
+	var expected map[string]string
+	if err := json.Unmarshal([]byte(question.Answer), &expected); err != nil || len(expected) == 0 {
+		LogError("Invalid matching answer key for question %d: %v", question.ID, err)
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	var given map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(userAnswer)), &given); err != nil {
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	normalizedGiven := make(map[string]string, len(given))
+	for prompt, match := range given {
+		normalizedGiven[NormalizeAnswer(prompt)] = NormalizeAnswer(match)
+	}
+
+	matched := 0
+	for prompt, match := range expected {
+		if normalizedGiven[NormalizeAnswer(prompt)] == NormalizeAnswer(match) {
+			matched++
+		}
+	}
+
+	return partialEvaluation(matched, len(expected), true)
+}
+
+// evaluateClozeAnswer checks each blank like a short open_text answer
+func evaluateClozeAnswer(question *models.Question, userAnswer string) models.AnswerEvaluation {
+	var expected []string
+	if err := json.Unmarshal([]byte(question.Answer), &expected); err != nil || len(expected) == 0 {
+		LogError("Invalid cloze answer key for question %d: %v", question.ID, err)
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	given, err := parseClozeAnswer(userAnswer)
+	if err != nil {
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonInvalidFormat}
+	}
+
+	// Keywords belong to the whole question, not to a single blank
+	config := answerMatcherConfig
+	config.UseKeywords = false
+
+	filled := 0
+	typos := false
+	for i := range expected {
+		if i >= len(given) {
+			break
+		}
+		blank := evaluateOpenTextAnswer(&models.Question{Answer: expected[i]}, given[i], config)
+		if blank.IsCorrect {
+			filled++
+			typos = typos || blank.Reason == ReasonTypoMatch
+		}
+	}
+
+	evaluation := partialEvaluation(filled, len(expected), len(given) == len(expected))
+	if evaluation.IsCorrect && typos {
+		evaluation.Reason = ReasonTypoMatch
+	}
+	return evaluation
+}
+
+// partialEvaluation turns right/total into a verdict, correct only when everything is right
+func partialEvaluation(right, total int, complete bool) models.AnswerEvaluation {
+	score := float64(right) / float64(total)
+	switch {
+	case right == total && complete:
+		return models.AnswerEvaluation{IsCorrect: true, Score: 1, Confidence: 1, Reason: ReasonExactMatch}
+	case right > 0:
+		return models.AnswerEvaluation{IsCorrect: false, Score: score, Confidence: 1, Reason: ReasonPartialMatch}
+	default:
+		return models.AnswerEvaluation{IsCorrect: false, Confidence: 1, Reason: ReasonNoMatch}
+	}
+}
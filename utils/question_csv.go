@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/adamspd/QuizzApi/models"
+)
+
+// QuestionCSVColumns is the column layout of question spreadsheets. On import columns are matched by
+// header name in any order; category, question and answer are required, id, status and unknown
+// columns are ignored. Choices, keywords, tags, sources and list
+// answers (multiple_select, ordering, cloze) are single cells whose items are joined by the list
+// separator; matching answers and numeric answers with a tolerance stay JSON objects.
+var QuestionCSVColumns = []string{"id", "category", "question", "question_type", "choices", "answer", "keywords", "tags", "explanation", "sources", "difficulty", "status"}
+
+// requiredCSVColumns must be present in the header of an imported spreadsheet
+var requiredCSVColumns = []string{"category", "question", "answer"}
+
+// listAnswerTypes are the question types whose answer cell is a separated list
+var listAnswerTypes = map[string]bool{"multiple_select": true, "ordering": true, "cloze": true}
+
+// DefaultCSVListSeparator joins the items of list cells, commas being common inside the items themselves
+const DefaultCSVListSeparator = "|"
+
+// QuestionCSVRecord lays a question out as a row matching QuestionCSVColumns
+func QuestionCSVRecord(q *models.Question, separator string) []string {
+	return []string{
+		strconv.Itoa(q.ID),
+		q.Category,
+		q.Question,
+		q.QuestionType,
+		strings.Join(q.Choices, separator),
+		CSVAnswerCell(q.Answer, separator),
+		strings.Join(q.Keywords, separator),
+		strings.Join(q.Tags, separator),
+		q.Explanation,
+		strings.Join(q.Sources, separator),
+		q.Difficulty,
+		q.Status,
+	}
+}
+
+// CSVAnswerCell writes a stored answer key as a cell, JSON arrays become separated lists
+func CSVAnswerCell(answer, separator string) string {
+	if strings.HasPrefix(strings.TrimSpace(answer), "[") {
+		var items []string
+		if err := json.Unmarshal([]byte(answer), &items); err == nil {
+			return strings.Join(items, separator)
+		}
+	}
+	return answer
+}
+
+// QuestionCSVReader reads a spreadsheet laid out as QuestionCSVColumns row by row, each question
+// carrying the line it starts on so errors can point at the row. Blank rows are skipped.
+type QuestionCSVReader struct {
+	reader    *csv.Reader
+	columns   map[string]int
+	separator string
+}
+
+// NewQuestionCSVReader reads and checks the header of the spreadsheet
+func NewQuestionCSVReader(r io.Reader, delimiter rune, separator string) (*QuestionCSVReader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("invalid CSV: the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Spreadsheet programs like to start UTF-8 files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, seen := columns[name]; !seen {
+			columns[name] = i
+		}
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid CSV: missing column '%s', expected columns: %s", name, strings.Join(QuestionCSVColumns, ", "))
+		}
+	}
+
+	return &QuestionCSVReader{reader: reader, columns: columns, separator: separator}, nil
+}
+
+// Next returns the question of the next non-blank row
+func (qr *QuestionCSVReader) Next() (*models.QuestionImport, error) {
+	for {
+		record, err := qr.reader.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		cell := func(name string) string {
+			if i, ok := qr.columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row, _ := qr.reader.FieldPos(0)
+		questionType := strings.ToLower(cell("question_type"))
+		return &models.QuestionImport{
+			Category:     cell("category"),
+			Question:     cell("question"),
+			QuestionType: questionType,
+			Choices:      splitCSVList(cell("choices"), qr.separator),
+			Answer:       CSVAnswerValue(questionType, cell("answer"), qr.separator),
+			Keywords:     splitCSVList(cell("keywords"), qr.separator),
+			Tags:         splitCSVList(cell("tags"), qr.separator),
+			Explanation:  cell("explanation"),
+			Sources:      splitCSVList(cell("sources"), qr.separator),
+			Difficulty:   cell("difficulty"),
+			Row:          row,
+		}, nil
+	}
+}
+
+// ParseQuestionCSV reads a whole spreadsheet laid out as QuestionCSVColumns into import questions
+func ParseQuestionCSV(r io.Reader, delimiter rune, separator string) ([]models.QuestionImport, error) {
+	reader, err := NewQuestionCSVReader(r, delimiter, separator)
+	if err != nil {
+		return nil, err
+	}
+
+	var questions []models.QuestionImport
+	for {
+		q, err := reader.Next()
+		if err == io.EOF {
+			return questions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *q)
+	}
+}
+
+// CSVAnswerValue reads an answer cell back into the form POST /import takes: JSON objects and
+// arrays are decoded, separated lists become arrays for the list answer types, anything else stays
+// a string (a comma-separated multiple_select answer is still split later on)
+func CSVAnswerValue(questionType, cell, separator string) interface{} {
+	if strings.HasPrefix(cell, "{") || strings.HasPrefix(cell, "[") {
+		var structured interface{}
+		if err := json.Unmarshal([]byte(cell), &structured); err == nil {
+			return structured
+		}
+	}
+
+	if listAnswerTypes[questionType] && strings.Contains(cell, separator) {
+		var items []interface{}
+		for _, item := range splitCSVList(cell, separator) {
+			items = append(items, item)
+		}
+		return items
+	}
+
+	return cell
+}
+
+func splitCSVList(cell, separator string) []string {
+	var items []string
+	for _, item := range strings.Split(cell, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/adamspd/QuizzApi/models"
+)
+
+// QuestionReader yields the questions of an import file one at a time, returning io.EOF at the end
+type QuestionReader interface {
+	Next() (*models.QuestionImport, error)
+}
+
+// QuestionJSONReader reads the questions array of a models.ImportRequest document one element at a
+// time, so a large file is never decoded at once. Other top-level fields are skipped.
+type QuestionJSONReader struct {
+	decoder *json.Decoder
+	count   int
+	done    bool
+}
+
+// NewQuestionJSONReader positions the reader on the first element of the questions array
+func NewQuestionJSONReader(r io.Reader) (*QuestionJSONReader, error) {
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("invalid JSON: expected an object with a questions array")
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+
+		if token == "questions" {
+			if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+				return nil, fmt.Errorf("invalid JSON: questions must be an array")
+			}
+			return &QuestionJSONReader{decoder: decoder}, nil
+		}
+
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+	}
+
+	return nil, fmt.Errorf("invalid JSON: no questions array found")
+}
+
+// Next decodes the next element of the questions array
+func (qr *QuestionJSONReader) Next() (*models.QuestionImport, error) {
+	if qr.done || !qr.decoder.More() {
+		qr.done = true
+		return nil, io.EOF
+	}
+
+	var q models.QuestionImport
+	if err := qr.decoder.Decode(&q); err != nil {
+		return nil, fmt.Errorf("invalid JSON in question %d: %v", qr.count+1, err)
+	}
+	qr.count++
+	return &q, nil
+}
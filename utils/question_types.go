@@ -0,0 +1,330 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuestionTypes lists every supported question type
+var QuestionTypes = []string{
+	"open_text",
+	"multiple_choice",
+	"true_false",
+	"multiple_select",
+	"numeric",  // answer is a number, or {"value": 1789, "tolerance": 0}
+	"ordering", // answer is the JSON array of the choices in the right order
+	"matching", // answer is a JSON object pairing each prompt with one of the choices
+	"cloze",    // question has ___ blanks, answer is the JSON array of the words that fill them
+}
+
+// clozeBlank is how a blank is written in the text of a cloze question
+var clozeBlank = regexp.MustCompile(`_{3,}`)
+
+// NumericAnswer is the answer key of a numeric question
+type NumericAnswer struct {
+	Value     *float64 `json:"value"`
+	Tolerance float64  `json:"tolerance"`
+}
+
+// IsValidQuestionType tells whether the type is one of QuestionTypes
+func IsValidQuestionType(questionType string) bool {
+	for _, t := range QuestionTypes {
+		if t == questionType {
+			return true
+		}
+	}
+	return false
+}
+
+// CountClozeBlanks returns how many blanks a cloze question text has
+func CountClozeBlanks(question string) int {
+	return len(clozeBlank.FindAllStringIndex(question, -1))
+}
+
+// ValidateQuestionAnswer checks that the answer and choices make sense for the question type and
+// returns them normalized for storage, e.g. a comma-separated multiple_select answer becomes a JSON array.
+func ValidateQuestionAnswer(questionType, question string, choices []string, answer string) (string, []string, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", nil, fmt.Errorf("empty answer")
+	}
+
+	switch questionType {
+	case "open_text", "true_false":
+		return answer, choices, nil
+
+	case "multiple_choice":
+		if len(choices) < 2 {
+			return "", nil, fmt.Errorf("multiple_choice questions must have at least 2 choices")
+		}
+		if !containsNormalized(choices, answer) {
+			return "", nil, fmt.Errorf("answer '%s' not found in choices", answer)
+		}
+		return answer, choices, nil
+
+	case "multiple_select":
+		if len(choices) < 2 {
+			return "", nil, fmt.Errorf("multiple_select questions must have at least 2 choices")
+		}
+		answers, err := parseAnswerList(answer)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, a := range answers {
+			if !containsNormalized(choices, a) {
+				return "", nil, fmt.Errorf("answer '%s' not found in choices", a)
+			}
+		}
+		answerJSON, _ := json.Marshal(answers)
+		return string(answerJSON), choices, nil
+
+	case "numeric":
+		return validateNumericAnswer(answer)
+
+	case "ordering":
+		return validateOrderingAnswer(answer, choices)
+
+	case "matching":
+		return validateMatchingAnswer(answer, choices)
+
+	case "cloze":
+		return validateClozeAnswer(question, answer)
+
+	default:
+		return "", nil, fmt.Errorf("invalid question type '%s', must be one of: %v", questionType, QuestionTypes)
+	}
+}
+
+func validateNumericAnswer(answer string) (string, []string, error) {
+	if strings.HasPrefix(answer, "{") {
+		key, err := ParseNumericAnswer(answer)
+		if err != nil {
+			return "", nil, err
+		}
+		answerJSON, _ := json.Marshal(key)
+		return string(answerJSON), nil, nil
+	}
+
+	value, err := ParseNumber(answer)
+	if err != nil {
+		return "", nil, fmt.Errorf("numeric answer must be a number or {\"value\": ..., \"tolerance\": ...}")
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), nil, nil
+}
+
+func validateOrderingAnswer(answer string, choices []string) (string, []string, error) {
+	var order []string
+	if err := json.Unmarshal([]byte(answer), &order); err != nil {
+		return "", nil, fmt.Errorf("ordering answer must be a JSON array of the items in the right order")
+	}
+	if len(order) < 2 {
+		return "", nil, fmt.Errorf("ordering questions must have at least 2 items")
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range order {
+		key := NormalizeAnswer(item)
+		if key == "" {
+			return "", nil, fmt.Errorf("ordering items cannot be empty")
+		}
+		if seen[key] {
+			return "", nil, fmt.Errorf("ordering item '%s' appears twice", item)
+		}
+		seen[key] = true
+	}
+
+	// Without explicit choices the items are served from the answer, shuffled
+	if len(choices) == 0 {
+		choices = append([]string(nil), order...)
+	} else {
+		if len(choices) != len(order) {
+			return "", nil, fmt.Errorf("ordering choices must be the same items as the answer")
+		}
+		// Same count, no repeat and all in the answer: the choices are exactly the answer items
+		used := make(map[string]bool)
+		for _, choice := range choices {
+			key := NormalizeAnswer(choice)
+			if !seen[key] {
+				return "", nil, fmt.Errorf("ordering choice '%s' is not in the answer", choice)
+			}
+			if used[key] {
+				return "", nil, fmt.Errorf("ordering choice '%s' appears twice", choice)
+			}
+			used[key] = true
+		}
+	}
+
+	answerJSON, _ := json.Marshal(order)
+	return string(answerJSON), choices, nil
+}
+
+func validateMatchingAnswer(answer string, choices []string) (string, []string, error) {
+	var pairs map[string]string
+	if err := json.Unmarshal([]byte(answer), &pairs); err != nil {
+		return "", nil, fmt.Errorf("matching answer must be a JSON object pairing each prompt with its match")
+	}
+	if len(pairs) < 2 {
+		return "", nil, fmt.Errorf("matching questions must have at least 2 pairs")
+	}
+
+	for prompt, match := range pairs {
+		if strings.TrimSpace(prompt) == "" || strings.TrimSpace(match) == "" {
+			return "", nil, fmt.Errorf("matching prompts and matches cannot be empty")
+		}
+	}
+
+	// Without explicit choices the matches are the options; extra choices act as distractors
+	if len(choices) == 0 {
+		unique := make(map[string]bool)
+		for _, match := range pairs {
+			if !unique[match] {
+				unique[match] = true
+				choices = append(choices, match)
+			}
+		}
+		sort.Strings(choices)
+	} else {
+		for _, match := range pairs {
+			if !containsNormalized(choices, match) {
+				return "", nil, fmt.Errorf("match '%s' not found in choices", match)
+			}
+		}
+	}
+
+	answerJSON, _ := json.Marshal(pairs)
+	return string(answerJSON), choices, nil
+}
+
+func validateClozeAnswer(question, answer string) (string, []string, error) {
+	blanks := CountClozeBlanks(question)
+	if blanks == 0 {
+		return "", nil, fmt.Errorf("cloze questions must contain at least one blank written as ___")
+	}
+
+	words, err := parseClozeAnswer(answer)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(words) != blanks {
+		return "", nil, fmt.Errorf("cloze question has %d blank(s) but the answer fills %d", blanks, len(words))
+	}
+	for _, word := range words {
+		if strings.TrimSpace(word) == "" {
+			return "", nil, fmt.Errorf("cloze answers cannot be empty")
+		}
+	}
+
+	answerJSON, _ := json.Marshal(words)
+	return string(answerJSON), nil, nil
+}
+
+// ParseNumericAnswer decodes the {"value", "tolerance"} form of a numeric answer key
+func ParseNumericAnswer(answer string) (*NumericAnswer, error) {
+	var key NumericAnswer
+	if err := json.Unmarshal([]byte(answer), &key); err != nil || key.Value == nil {
+		return nil, fmt.Errorf("numeric answer must be a number or {\"value\": ..., \"tolerance\": ...}")
+	}
+	if key.Tolerance < 0 {
+		return nil, fmt.Errorf("numeric tolerance cannot be negative")
+	}
+	return &key, nil
+}
+
+// ParseNumber reads a number the way learners type it: "67 000 000", "1,000,000", "3,5" or "3.5".
+// A comma is the decimal separator only when it is the only separator and 1 or 2 digits follow it,
+// otherwise commas group thousands.
+func ParseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "_", "").Replace(s)
+	if comma := strings.Index(s, ","); comma >= 0 && !strings.Contains(s, ".") && strings.Count(s, ",") == 1 &&
+		len(s)-comma-1 >= 1 && len(s)-comma-1 <= 2 {
+		s = strings.Replace(s, ",", ".", 1)
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseAnswerList reads a JSON array answer, or a comma-separated one
+func parseAnswerList(answer string) ([]string, error) {
+	var answers []string
+	if strings.HasPrefix(answer, "[") {
+		if err := json.Unmarshal([]byte(answer), &answers); err != nil {
+			return nil, fmt.Errorf("invalid JSON array in answer: %v", err)
+		}
+	} else {
+		for _, a := range strings.Split(answer, ",") {
+			answers = append(answers, strings.TrimSpace(a))
+		}
+	}
+
+	var nonEmpty []string
+	for _, a := range answers {
+		if strings.TrimSpace(a) != "" {
+			nonEmpty = append(nonEmpty, a)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return nil, fmt.Errorf("empty answer array")
+	}
+	return nonEmpty, nil
+}
+
+// parseClozeAnswer reads the words of a cloze answer, a plain string fills a single blank
+func parseClozeAnswer(answer string) ([]string, error) {
+	answer = strings.TrimSpace(answer)
+	if !strings.HasPrefix(answer, "[") {
+		return []string{answer}, nil
+	}
+
+	var words []string
+	if err := json.Unmarshal([]byte(answer), &words); err != nil {
+		return nil, fmt.Errorf("cloze answer must be a JSON array with one entry per blank")
+	}
+	return words, nil
+}
+
+func containsNormalized(choices []string, answer string) bool {
+	for _, choice := range choices {
+		if NormalizeAnswer(choice) == NormalizeAnswer(answer) {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits on the explanation and sources of a question
+const (
+	MaxExplanationLength = 10000
+	MaxQuestionSources   = 20
+	MaxSourceLength      = 1000
+)
+
+// ValidateQuestionExplanation trims the explanation and sources of a question, dropping blank sources
+func ValidateQuestionExplanation(explanation string, sources []string) (string, []string, error) {
+	explanation = strings.TrimSpace(explanation)
+	if utf8.RuneCountInString(explanation) > MaxExplanationLength {
+		return "", nil, fmt.Errorf("explanation must be at most %d characters", MaxExplanationLength)
+	}
+
+	var kept []string
+	for _, source := range sources {
+		if source = strings.TrimSpace(source); source == "" {
+			continue
+		}
+		if utf8.RuneCountInString(source) > MaxSourceLength {
+			return "", nil, fmt.Errorf("sources must be at most %d characters each", MaxSourceLength)
+		}
+		kept = append(kept, source)
+	}
+	if len(kept) > MaxQuestionSources {
+		return "", nil, fmt.Errorf("at most %d sources are allowed", MaxQuestionSources)
+	}
+
+	return explanation, kept, nil
+}
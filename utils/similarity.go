@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"math"
+	"strings"
+)
+
+// DuplicateThreshold is the similarity from which two questions are reported as likely duplicates
+const DuplicateThreshold = 0.75
+
+// similarityNormalization folds accents and punctuation regardless of the answer matcher settings
+var similarityNormalization = AnswerMatcherConfig{FoldAccents: true, StripPunctuation: true}
+
+// questionStopwords carry no meaning on their own, "Qui est le président ?" is about "président"
+var questionStopwords = map[string]bool{
+	"a": true, "au": true, "aux": true, "avec": true, "ce": true, "ces": true, "c": true, "d": true, "dans": true,
+	"de": true, "des": true, "du": true, "elle": true, "en": true, "est": true, "et": true, "il": true, "l": true,
+	"la": true, "le": true, "les": true, "leur": true, "n": true, "ne": true, "on": true, "ou": true, "par": true,
+	"pas": true, "pour": true, "qu": true, "que": true, "quel": true, "quelle": true, "quelles": true, "quels": true,
+	"qui": true, "quoi": true, "sa": true, "se": true, "ses": true, "son": true, "sont": true, "sur": true, "un": true,
+	"une": true, "y": true, "comment": true, "combien": true, "quand": true, "s": true,
+	"the": true, "is": true, "of": true, "what": true, "who": true, "which": true, "an": true, "in": true,
+}
+
+// QuestionFingerprint is the comparable form of a question text: its character trigrams and its
+// meaningful words
+type QuestionFingerprint struct {
+	trigrams map[string]struct{}
+	tokens   map[string]struct{}
+}
+
+// NewQuestionFingerprint normalizes a question text and extracts its trigrams and content words
+func NewQuestionFingerprint(text string) QuestionFingerprint {
+	normalized := NormalizeOpenAnswer(text, similarityNormalization)
+
+	fp := QuestionFingerprint{
+		trigrams: make(map[string]struct{}),
+		tokens:   make(map[string]struct{}),
+	}
+
+	padded := []rune(" " + normalized + " ")
+	for i := 0; i+3 <= len(padded); i++ {
+		fp.trigrams[string(padded[i:i+3])] = struct{}{}
+	}
+
+	for _, word := range strings.Fields(normalized) {
+		if questionStopwords[word] {
+			continue
+		}
+		// Crude plural folding, enough for "valeurs" to meet "valeur"
+		if len(word) > 3 && (strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x")) {
+			word = word[:len(word)-1]
+		}
+		fp.tokens[word] = struct{}{}
+	}
+
+	return fp
+}
+
+// Tokens returns the content words of the question, two questions sharing none of them can not
+// reach DuplicateThreshold
+func (fp QuestionFingerprint) Tokens() []string {
+	tokens := make([]string, 0, len(fp.tokens))
+	for token := range fp.tokens {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// Similarity scores two questions between 0 and 1. Half of it is the trigram Dice coefficient, which
+// tolerates typos and accents, the other half the share of the shorter question's content words found
+// in the longer one, so a question that only adds details to another still scores high.
+func (fp QuestionFingerprint) Similarity(other QuestionFingerprint) float64 {
+	if len(fp.trigrams) == 0 || len(other.trigrams) == 0 {
+		return 0
+	}
+
+	dice := 2 * float64(intersectionSize(fp.trigrams, other.trigrams)) / float64(len(fp.trigrams)+len(other.trigrams))
+
+	containment := 0.0
+	if smaller := math.Min(float64(len(fp.tokens)), float64(len(other.tokens))); smaller > 0 {
+		containment = float64(intersectionSize(fp.tokens, other.tokens)) / smaller
+	} else if len(fp.tokens) == len(other.tokens) {
+		// Questions made only of stopwords are compared on their trigrams alone
+		containment = dice
+	}
+
+	return math.Round((dice+containment)/2*100) / 100
+}
+
+func intersectionSize(a, b map[string]struct{}) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	count := 0
+	for key := range a {
+		if _, ok := b[key]; ok {
+			count++
+		}
+	}
+	return count
+}
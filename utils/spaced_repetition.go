@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math"
+	"time"
+
+	"github.com/adamspd/QuizzApi/models"
+)
+
+const (
+	// DefaultEaseFactor is the SM-2 starting ease of a new card
+	DefaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+)
+
+// ReviewQuality maps an answer to the SM-2 0-5 quality scale
+func ReviewQuality(isCorrect bool) int {
+	if isCorrect {
+		return 4
+	}
+	return 1
+}
+
+// ScheduleReview applies the SM-2 algorithm to the schedule after a review of the given quality.
+// A nil schedule starts a new card.
+func ScheduleReview(schedule *models.QuestionSchedule, quality int, now time.Time) *models.QuestionSchedule {
+	next := models.QuestionSchedule{EaseFactor: DefaultEaseFactor}
+	if schedule != nil {
+		next = *schedule
+	}
+
+	if quality >= 3 {
+		switch next.Repetitions {
+		case 0:
+			next.IntervalDays = 1
+		case 1:
+			next.IntervalDays = 6
+		default:
+			next.IntervalDays = int(math.Round(float64(next.IntervalDays) * next.EaseFactor))
+		}
+		next.Repetitions++
+	} else {
+		// Forgetting a card that had been learned counts as a lapse
+		if next.Repetitions > 0 {
+			next.Lapses++
+		}
+		next.Repetitions = 0
+		next.IntervalDays = 1
+	}
+
+	q := float64(5 - quality)
+	next.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if next.EaseFactor < minEaseFactor {
+		next.EaseFactor = minEaseFactor
+	}
+
+	next.LastReviewedAt = now
+	next.DueAt = now.AddDate(0, 0, next.IntervalDays)
+	return &next
+}
@@ -36,29 +36,19 @@ func NormalizeAnswer(answer string) string {
 	return normalized
 }
 
+// CheckAnswer tells whether the answer is correct, see EvaluateAnswer for the reason behind the verdict
 func CheckAnswer(question *models.Question, userAnswer string) bool {
-	switch question.QuestionType {
-	case "open_text":
-		return NormalizeAnswer(userAnswer) == NormalizeAnswer(question.Answer)
-
-	case "multiple_choice", "true_false":
-		return NormalizeAnswer(userAnswer) == NormalizeAnswer(question.Answer)
-
-	case "multiple_select":
-		return checkMultipleSelectAnswer(question.Answer, userAnswer)
-
-	default:
-		LogError("Unknown question type: %s", question.QuestionType)
-		return false
-	}
+	return EvaluateAnswer(question, userAnswer).IsCorrect
 }
 
-func checkMultipleSelectAnswer(correctAnswerJSON, userAnswer string) bool {
+// scoreMultipleSelectAnswer grades a multiple_select answer. It is correct only when the selection
+// is exactly right; the score gives partial credit as (right picks - wrong picks) / expected picks.
+func scoreMultipleSelectAnswer(correctAnswerJSON, userAnswer string) (bool, float64) {
 	// Parse correct answers from JSON
 	var correctAnswers []string
 	if err := json.Unmarshal([]byte(correctAnswerJSON), &correctAnswers); err != nil {
 		LogError("Failed to parse multiple_select correct answer: %v", err)
-		return false
+		return false, 0
 	}
 
 	// Parse user answer - could be JSON array or comma-separated
@@ -69,7 +59,7 @@ func checkMultipleSelectAnswer(correctAnswerJSON, userAnswer string) bool {
 		// It's JSON array from frontend
 		if err := json.Unmarshal([]byte(userAnswer), &userAnswers); err != nil {
 			LogError("Failed to parse user JSON answer: %v", err)
-			return false
+			return false, 0
 		}
 	} else {
 		// Fallback: comma-separated
@@ -83,10 +73,9 @@ func checkMultipleSelectAnswer(correctAnswerJSON, userAnswer string) bool {
 		}
 	}
 
-	// Quick length check
-	if len(correctAnswers) != len(userAnswers) {
-		LogDebug("Answer length mismatch: expected %d, got %d", len(correctAnswers), len(userAnswers))
-		return false
+	if len(correctAnswers) == 0 {
+		LogError("multiple_select question has no correct answers")
+		return false, 0
 	}
 
 	// Create a set of normalized correct answers for O(1) lookup
@@ -97,18 +86,34 @@ func checkMultipleSelectAnswer(correctAnswerJSON, userAnswer string) bool {
 		LogDebug("Added to correct set: '%s'", normalizedAnswer)
 	}
 
-	// Check if all user answers are in the correct set
+	// Count right and wrong picks, a choice picked twice only counts once
+	picked := make(map[string]bool)
+	right, wrong := 0, 0
 	for _, userAns := range userAnswers {
 		normalizedUserAns := NormalizeAnswer(userAns)
+		if picked[normalizedUserAns] {
+			continue
+		}
+		picked[normalizedUserAns] = true
+
 		LogDebug("Checking user answer: '%s'", normalizedUserAns)
-		if !correctSet[normalizedUserAns] {
+		if correctSet[normalizedUserAns] {
+			right++
+		} else {
 			LogDebug("User answer '%s' not found in correct answers", normalizedUserAns)
-			return false
+			wrong++
 		}
 	}
 
-	LogDebug("All user answers matched!")
-	return true
+	isCorrect := wrong == 0 && right == len(correctSet)
+	score := float64(right-wrong) / float64(len(correctSet))
+	if score < 0 {
+		score = 0
+	}
+
+	LogDebug("multiple_select: %d right, %d wrong out of %d -> correct: %t, score: %.2f",
+		right, wrong, len(correctSet), isCorrect, score)
+	return isCorrect, score
 }
 
 // Validation utilities